@@ -8,12 +8,17 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/vinser/pacmantea/internal/config"
 	"github.com/vinser/pacmantea/internal/model"
-	"github.com/vinser/pacmantea/internal/sound"
+	"github.com/vinser/pacmantea/internal/replay"
+	"github.com/vinser/pacmantea/internal/state"
 )
 
 func main() {
 	// Define the -config flag
 	configFlag := flag.Bool("config", false, "Generate a default config.yml file in the config directory")
+	// Define the -seed flag
+	seedFlag := flag.Uint64("seed", 0, "Seed for reproducible games (0 for a random seed)")
+	// Define the -profile flag
+	profileFlag := flag.String("profile", "", "Player profile to play with (the last used one if empty)")
 	flag.Parse()
 
 	// If -config flag is set, write the default config.yml and exit
@@ -26,12 +31,51 @@ func main() {
 		return
 	}
 
-	// Run the game
-	model := model.New()
-	model.PlaySound(sound.BEGINNING)
+	// Play a recorded game back
+	if flag.Arg(0) == "replay" {
+		if flag.NArg() != 2 {
+			log.Fatal("Usage: pacmantea replay <file>")
+		}
+		r, err := replay.Load(flag.Arg(1))
+		if err != nil {
+			log.Fatalf("Failed to load replay: %v", err)
+		}
+		p := tea.NewProgram(model.NewPlayback(r))
+		if _, err := p.Run(); err != nil {
+			fmt.Println("Error running program:", err)
+		}
+		return
+	}
+
+	// Use the player profile
+	profile := *profileFlag
+	if profile == "" {
+		profile = state.LastProfile()
+	}
+	if err := state.SetProfile(profile); err != nil {
+		log.Fatalf("Failed to use profile %q: %v", profile, err)
+	}
 
-	p := tea.NewProgram(model)
-	if _, err := p.Run(); err != nil {
+	// Manage the saved progress of the profile
+	if flag.Arg(0) == "save" {
+		if err := state.SetStore(config.Load().SaveFormat); err != nil {
+			log.Fatal(err)
+		}
+		if err := saveCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Run the game
+	p := tea.NewProgram(model.NewMenu(*seedFlag), tea.WithReportFocus())
+	final, err := p.Run()
+	if err != nil {
 		fmt.Println("Error running program:", err)
 	}
+	if mn, ok := final.(*model.Menu); ok {
+		for _, warning := range mn.Warnings() {
+			fmt.Println(warning)
+		}
+	}
 }
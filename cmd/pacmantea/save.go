@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vinser/pacmantea/internal/snapshot"
+	"github.com/vinser/pacmantea/internal/state"
+)
+
+const saveUsage = "Usage: pacmantea save export > file.json | pacmantea save import <file.json> | pacmantea save reset"
+
+// Export, import or reset the saved progress of the profile
+func saveCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(saveUsage)
+	}
+	switch args[0] {
+	case "export":
+		if len(args) != 1 {
+			return errors.New(saveUsage)
+		}
+		data, err := state.Export()
+		if err != nil {
+			return fmt.Errorf("failed to export the save of profile %q: %w", state.Profile(), err)
+		}
+		_, err = fmt.Println(string(data))
+		return err
+	case "import":
+		if len(args) != 2 {
+			return errors.New(saveUsage)
+		}
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		if err := state.Import(data); err != nil {
+			return fmt.Errorf("failed to import %s: %w", args[1], err)
+		}
+		fmt.Fprintf(os.Stderr, "The save of profile %q has been imported from %s, the previous one is kept as a backup.\n", state.Profile(), args[1])
+		return nil
+	case "reset":
+		if len(args) != 1 {
+			return errors.New(saveUsage)
+		}
+		if err := state.Reset(); err != nil {
+			return fmt.Errorf("failed to reset the save of profile %q: %w", state.Profile(), err)
+		}
+		if err := snapshot.Remove(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "The progress of profile %q has been reset, the previous save is kept as a backup.\n", state.Profile())
+		return nil
+	}
+	return errors.New(saveUsage)
+}
@@ -1,39 +1,143 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log"
 	"os"
 	"path"
+	"slices"
 
 	"github.com/vinser/pacmantea/internal/embeddata"
 	"gopkg.in/yaml.v3"
 )
 
 type Level struct {
-	Name           string   `yaml:"name"`
-	DifficultyName string   `yaml:"difficulty"`
-	Maze           []string `yaml:"maze"`
-	PacmanBadge    string   `yaml:"pacman_badge"` // Badge style for Pac-Man
-	GhostBadges    string   `yaml:"ghost_badges"` // Badge style for ghosts
+	Name           string            `yaml:"name"`
+	DifficultyName string            `yaml:"difficulty"`
+	Maze           []string          `yaml:"maze"`
+	PacmanBadge    string            `yaml:"pacman_badge"` // Badge style for Pac-Man
+	GhostBadges    string            `yaml:"ghost_badges"` // Badge style for ghosts
+	Strategies     map[string]string `yaml:"strategies"`   // Ghost strategies by ghost name, override the difficulty ones
+	Ghosts         []string          `yaml:"ghosts"`       // Ids of the ghosts in the level, all ghosts if not set
+	Fruit          FruitSettings     `yaml:"fruit"`
+	ParTime        int               `yaml:"par_time"`        // Seconds to complete the level in for a time bonus, no bonus if not set
+	Cells          string            `yaml:"cells,omitempty"` // Shape of the maze tiles on the screen, the theme one if not set
+	Theme          string            `yaml:"theme,omitempty"` // Name of the theme of the level, the default colours if not set
+}
+
+// Shapes of the maze tiles on the screen
+const (
+	NarrowCells = "narrow" // One column per tile
+	SquareCells = "square" // Two columns per tile, which looks square in most terminal fonts
+)
+
+// Colours and glyphs of the game. Colours are ANSI numbers or hex codes, which are
+// brought down to what the terminal supports. Empty colours are the default ones.
+type Theme struct {
+	Wall       string            `yaml:"wall"`
+	Dot        string            `yaml:"dot"`
+	Energizer  string            `yaml:"energizer"`
+	Door       string            `yaml:"door"`
+	Pacman     string            `yaml:"pacman"`
+	Rampant    string            `yaml:"rampant"`    // Pac-Man in the rampant state
+	Ghosts     map[string]string `yaml:"ghosts"`     // Colours of ghosts by id, the ghost definitions ones if not set
+	Frightened string            `yaml:"frightened"` // Ghosts frightened by rampant Pac-Man
+	Eyes       string            `yaml:"eyes"`       // Eaten ghosts returning home
+	HUD        string            `yaml:"hud"`        // Titles and highlights of the menu and HUD
+	Walls      string            `yaml:"walls"`      // Wall glyph set: classic, single, rounded, heavy, double, ascii or blocks, classic if not set
+	Cells      string            `yaml:"cells"`      // Shape of the maze tiles on the screen, narrow if not set
+}
+
+// Bonus fruit settings of a level
+type FruitSettings struct {
+	Position []int   `yaml:"position"` // Column and row of the maze cell where fruit appears, Pac-Man's starting cell if not set
+	Dots     []int   `yaml:"dots"`     // Fruit appears when this many dots are eaten
+	Timeout  int     `yaml:"timeout"`  // Seconds before uneaten fruit vanishes
+	Table    []Fruit `yaml:"table"`    // Fruit in order of appearance, the last one repeats
+}
+
+type Fruit struct {
+	Name   string `yaml:"name"`
+	Symbol string `yaml:"symbol"`
+	Color  string `yaml:"color"` // Terminal color of the symbol
+	Points int    `yaml:"points"`
 }
 
 type Difficulty struct {
-	GhostSpeed       int `yaml:"ghost_speed"`
-	RampantDuration  int `yaml:"rampant_duration"`
-	CooldownDuration int `yaml:"cooldown_duration"`
-	RevivalTimer     int `yaml:"revival_timer"`
-	SpeedBonus       int `yaml:"speed_bonus"` // base points for each second in formula speedBonus * wonGames * seconds
+	PacmanSpeed      int               `yaml:"pacman_speed"` // Pac-Man cells per second
+	GhostSpeed       int               `yaml:"ghost_speed"`
+	RampantDuration  int               `yaml:"rampant_duration"`
+	CooldownDuration int               `yaml:"cooldown_duration"`
+	RevivalTimer     int               `yaml:"revival_timer"`
+	SpeedBonus       int               `yaml:"speed_bonus"`   // base points for each second under the level par time, multiplied by one plus the number of games won
+	ModeSchedule     []int             `yaml:"mode_schedule"` // Seconds of alternating scatter and chase phases, the mode after the last phase lasts forever
+	GhostRelease     GhostRelease      `yaml:"ghost_release"`
+	Navigation       string            `yaml:"navigation"`     // How ghosts find their way to targets, greedy if not set
+	Strategies       map[string]string `yaml:"strategies"`     // Ghost strategies by ghost name
+	StartingLives    int               `yaml:"starting_lives"` // Lives at the start of a game, 5 if not set
+	MaxLives         int               `yaml:"max_lives"`      // Most lives the player can have, no limit if not set
+	ExtraLives       ExtraLives        `yaml:"extra_lives"`
+}
+
+// Game scores that award an extra life
+type ExtraLives struct {
+	Scores []int `yaml:"scores"` // Each of these scores awards an extra life once
+	Every  int   `yaml:"every"`  // Every multiple of this score awards an extra life, none if not set
+}
+
+// Ghost navigation modes
+const (
+	GreedyNavigation      = "greedy"      // Step to the neighbour cell closest to the target in a straight line, as in the arcade
+	PathfindingNavigation = "pathfinding" // Follow the shortest path through the maze
+)
+
+// Rules to release ghosts from the ghost house
+type GhostRelease struct {
+	Dots  map[string]int `yaml:"dots"`  // Dots to be eaten before the ghost leaves the house by ghost name
+	Timer int            `yaml:"timer"` // Seconds without eating a dot after which the next ghost is released anyway
+}
+
+// Ghost definition
+type Ghost struct {
+	ID       string            `yaml:"id"` // Maze marker letter of the ghost
+	Name     string            `yaml:"name"`
+	Color    string            `yaml:"color"`    // Terminal color of the ghost
+	Badges   map[string]string `yaml:"badges"`   // Badges by badge style
+	Strategy string            `yaml:"strategy"` // Ghost strategy in chase mode, difficulties and levels may override it
+	Speed    float64           `yaml:"speed"`    // Speed multiplier, 1 if not set
+	Home     string            `yaml:"home"`     // Corner the ghost heads for in scatter mode
+}
+
+// Home corners of ghosts
+const (
+	TopLeft     = "top-left"
+	TopRight    = "top-right"
+	BottomLeft  = "bottom-left"
+	BottomRight = "bottom-right"
+)
+
+// Classic ghosts for configurations without ghost definitions
+var DefaultGhosts = []Ghost{
+	{ID: "B", Name: "Blinky", Color: "1", Strategy: "chase", Home: TopRight},
+	{ID: "I", Name: "Inky", Color: "6", Strategy: "random", Home: BottomRight},
+	{ID: "P", Name: "Pinky", Color: "201", Strategy: "ambush", Home: TopLeft},
+	{ID: "Y", Name: "Clyde", Color: "208", Strategy: "cagey", Home: BottomLeft},
 }
 
 type Badges struct {
 	Pacman map[string]map[string]string `yaml:"pacman"` // Badge styles for Pac-Man
-	Ghosts map[string]map[string]string `yaml:"ghosts"` // Badge styles for ghosts
+	Ghosts map[string]map[string]string `yaml:"ghosts"` // Badge styles for ghosts by ghost id, used when ghost definitions have no badges
 }
 
 type Config struct {
+	SaveFormat   string                `yaml:"save_format"` // Format of the save file: signed (the default), plain or encrypted
 	Badges       Badges                `yaml:"badges"`
+	Ghosts       []Ghost               `yaml:"ghosts"`
 	Difficulties map[string]Difficulty `yaml:"difficulties"`
 	Levels       []Level               `yaml:"levels"`
+	Themes       map[string]Theme      `yaml:"themes,omitempty"` // Themes by name
 }
 
 func WriteDefaultConfig() error {
@@ -84,3 +188,65 @@ func Load() Config {
 	}
 	return config
 }
+
+// LevelGhosts returns the definitions of the ghosts in the level
+func (c Config) LevelGhosts(level Level) ([]Ghost, error) {
+	ghosts := c.Ghosts
+	if len(ghosts) == 0 {
+		ghosts = DefaultGhosts
+	}
+	if len(level.Ghosts) == 0 {
+		return ghosts, nil
+	}
+	found := make([]Ghost, 0, len(level.Ghosts))
+	for _, id := range level.Ghosts {
+		i := slices.IndexFunc(ghosts, func(ghost Ghost) bool { return ghost.ID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("level %s has unknown ghost %q", level.Name, id)
+		}
+		found = append(found, ghosts[i])
+	}
+	return found, nil
+}
+
+// GhostBadge returns the badge of the ghost in the badge style
+func (c Config) GhostBadge(ghost Ghost, style string) string {
+	if badge, ok := ghost.Badges[style]; ok {
+		return badge
+	}
+	if badge, ok := c.Badges.Ghosts[style][ghost.ID]; ok {
+		return badge
+	}
+	return ghost.ID
+}
+
+// Hash returns a short fingerprint of the configuration of the game play
+func Hash(c Config) string {
+	c.SaveFormat = ""   // The save format does not change the game
+	c.Themes = nil      // Nor does the look of the maze
+	c.Badges = Badges{} // Nor do the badges and colours of the characters
+	c.Ghosts = slices.Clone(c.Ghosts)
+	for i := range c.Ghosts {
+		c.Ghosts[i].Color = ""
+		c.Ghosts[i].Badges = nil
+	}
+	c.Levels = slices.Clone(c.Levels)
+	for i := range c.Levels {
+		c.Levels[i].PacmanBadge = ""
+		c.Levels[i].GhostBadges = ""
+		c.Levels[i].Cells = ""
+		c.Levels[i].Theme = ""
+		table := slices.Clone(c.Levels[i].Fruit.Table)
+		for j := range table {
+			table[j].Symbol = ""
+			table[j].Color = ""
+		}
+		c.Levels[i].Fruit.Table = table
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+// Changing the look of the game keeps saved games, replays and leaderboards
+func TestHashIgnoresLooks(t *testing.T) {
+	c := Load()
+	hash := Hash(c)
+	looks := Load()
+	looks.Themes = map[string]Theme{"dark": {Wall: "8"}}
+	looks.Badges.Pacman = map[string]map[string]string{"arrows": {"right": ">"}}
+	looks.Badges.Ghosts = map[string]map[string]string{"arrows": {"B": "^"}}
+	for i := range looks.Ghosts {
+		looks.Ghosts[i].Color = "7"
+		looks.Ghosts[i].Badges = map[string]string{"arrows": "^"}
+	}
+	for i := range looks.Levels {
+		looks.Levels[i].PacmanBadge = "arrows"
+		looks.Levels[i].GhostBadges = "arrows"
+		looks.Levels[i].Cells = SquareCells
+		looks.Levels[i].Theme = "dark"
+		for j := range looks.Levels[i].Fruit.Table {
+			looks.Levels[i].Fruit.Table[j].Symbol = "*"
+			looks.Levels[i].Fruit.Table[j].Color = "7"
+		}
+	}
+	if Hash(looks) != hash {
+		t.Error("the look of the game changes the configuration hash")
+	}
+	if c.Ghosts[0].Color == "" || c.Levels[0].Theme == "" || c.Levels[0].Fruit.Table[0].Symbol == "" {
+		t.Error("hashing changes the configuration")
+	}
+	play := Load()
+	play.Levels[0].ParTime++
+	if Hash(play) == hash {
+		t.Error("the game play does not change the configuration hash")
+	}
+}
@@ -0,0 +1,314 @@
+// Package engine implements the game rules independently of any user interface.
+// A Game is advanced one tick at a time with Step, which returns the events
+// that happened during the tick so that front ends can play sounds and render.
+package engine
+
+import (
+	"errors"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// TicksPerSecond is the number of game ticks in one second of game time.
+// All timers of the game are expressed in ticks.
+const TicksPerSecond = 20
+
+const (
+	ghostBonus         = 40 // 40 points for first eated ghost in rampant state, 80 for second...
+	defaultPacmanSpeed = 4  // Pac-Man cells per second if the difficulty has no speed
+)
+
+type Entity struct {
+	Position utils.Point
+	Move     utils.Direction
+	Name     string
+	Badge    rune
+}
+
+type Pacman struct {
+	Entity
+	NextMove      utils.Direction // Buffered turn applied at the first cell where it is possible
+	RampantState  bool
+	CooldownState bool
+}
+
+type Ghost struct {
+	Entity
+	State        GhostState
+	Frightened   bool        // The ghost runs away and can be eaten
+	Reverse      bool        // The ghost turns back on its next move
+	Home         utils.Point // Corner the ghost heads for in scatter mode
+	Strategy     string      // Name of the ghost strategy in chase mode
+	Speed        int         // Speed multiplier in percent
+	MoveCredit   int         // Progress to the next move, the ghost moves when it reaches moveCost
+	RevivalPoint utils.Point
+	ReleaseDots  int // Dots to be eaten before the ghost leaves the house
+	HouseTimer   int // Ticks the revived ghost stays in the house
+}
+
+// Input is the player's command for a single tick
+type Input struct {
+	Dir utils.Direction // Direction to turn to as soon as possible, zero means no change
+}
+
+type EventType int
+
+const (
+	DotEaten EventType = iota
+	EnergizerEaten
+	GhostEaten
+	PacmanDied
+	LevelWon
+	FruitEaten
+)
+
+// Event is something that happened during a tick
+type Event struct {
+	Type   EventType
+	Name   string // Name of the involved entity, if any
+	Points int    // Points scored by the event
+	Kind   int    // Index of the eaten fruit in the fruit table of the level
+}
+
+type Game struct {
+	Level         config.Level
+	Difficulty    config.Difficulty
+	Grid          Grid
+	Pacman        Pacman
+	Ghosts        []Ghost
+	Score         int
+	Tick          int
+	Won           bool
+	Lost          bool
+	GhostsEaten   int // Ghosts eaten during the current rampant state
+	RampantTimer  int // Ticks left in rampant state
+	CooldownTimer int // Ticks left in cooldown state
+	Mode          GhostMode
+	ModePhase     int // Index of the current phase in the mode schedule
+	ModeTimer     int // Ticks left in the current phase, zero when it lasts forever
+	House         *House
+	DotsEaten     int // Dots eaten since the start of the level
+	DotsLeft      int // Dots left in the maze, the level is won when there are none
+	IdleTimer     int // Ticks since the last dot was eaten
+	Fruit         *Fruit
+	FruitCell     utils.Point // Cell where fruit appears
+	FruitsShown   int         // Fruits that have appeared in the level
+	Rng           *utils.Rand
+
+	graph  *mazeGraph
+	events []Event
+}
+
+// Seconds converts seconds of game time to ticks
+func Seconds(s int) int {
+	return s * TicksPerSecond
+}
+
+// New returns a game ready to play the level with the given difficulty and ghosts.
+// All random decisions of the game are drawn from rng.
+func New(level config.Level, difficulty config.Difficulty, ghosts []config.Ghost, rng *utils.Rand) (*Game, error) {
+	maze := level.Maze
+	// Ensure the maze has a minimum size of 5x5
+	if len(maze) < 5 || len([]rune(maze[0])) < 5 {
+		return nil, errors.New("the maze must be at least 5x5")
+	}
+	markers, err := ghostMarkers(ghosts)
+	if err != nil {
+		return nil, err
+	}
+	grid, err := newGrid(maze, markers)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Game{
+		Level:      level,
+		Difficulty: difficulty,
+		Rng:        rng,
+		Grid:       grid,
+		House:      findHouse(grid),
+	}
+	if g.House != nil {
+		for cell := range g.House.Cells {
+			g.Grid.Set(cell.X, cell.Y, Empty) // Pac-Man can't get into the ghost house to eat
+		}
+	}
+	g.DotsLeft = g.Grid.Count(Dot)
+	g.placeEntities(maze, ghosts, markers)
+	if err := g.assignStrategies(); err != nil {
+		return nil, err
+	}
+	g.Mode = Chase
+	if len(difficulty.ModeSchedule) > 0 {
+		g.Mode = Scatter
+		g.ModeTimer = max(1, Seconds(difficulty.ModeSchedule[0]))
+	}
+	g.graph = newGraph(g)
+	if g.FruitCell, err = g.findFruitCell(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Over reports whether the level is finished either way
+func (g *Game) Over() bool {
+	return g.Won || g.Lost
+}
+
+// Step advances the game by one tick and returns the events of the tick
+func (g *Game) Step(in Input) []Event {
+	if g.Over() {
+		return nil
+	}
+	g.events = nil
+	g.Tick++
+	g.updateTimers()
+	if in.Dir != (utils.Direction{}) {
+		g.Pacman.NextMove = in.Dir
+	}
+	if g.Tick%g.pacmanMoveInterval() == 0 {
+		g.movePacman()
+	}
+	if !g.Over() {
+		g.moveGhosts()
+		g.checkGhostCollisions()
+	}
+	return g.events
+}
+
+func (g *Game) emit(t EventType, name string, points int) {
+	g.events = append(g.events, Event{Type: t, Name: name, Points: points})
+}
+
+// Ghosts gain their speed in percent times the difficulty ghost speed as move credit
+// every tick and move one cell for every moveCost of credit
+const moveCost = 100 * TicksPerSecond
+
+// Number of ticks between two Pac-Man moves
+func (g *Game) pacmanMoveInterval() int {
+	speed := g.Difficulty.PacmanSpeed
+	if speed <= 0 {
+		speed = defaultPacmanSpeed
+	}
+	return max(1, TicksPerSecond/speed)
+}
+
+func (g *Game) updateTimers() {
+	g.updateMode()
+	if g.Pacman.RampantState {
+		if g.RampantTimer > 0 {
+			g.RampantTimer--
+			if g.RampantTimer == 0 {
+				// Start cooldown
+				g.Pacman.CooldownState = true
+				g.CooldownTimer = Seconds(g.Difficulty.CooldownDuration)
+			}
+		} else if g.CooldownTimer--; g.CooldownTimer <= 0 {
+			// End cooldown and fully reset Pac-Man's state
+			g.Pacman.RampantState = false
+			g.Pacman.CooldownState = false
+			for i := range g.Ghosts {
+				g.Ghosts[i].Frightened = false
+			}
+		}
+	}
+	g.updateHouse()
+	g.updateFruit()
+}
+
+// Pac-Man moves one cell forward until blocked, turning to the buffered direction when possible
+func (g *Game) movePacman() {
+	if next := g.Pacman.NextMove; next != (utils.Direction{}) && g.canPacmanMove(g.tunnelMove(g.Pacman.Position.X+next.X), g.Pacman.Position.Y+next.Y) {
+		g.Pacman.Move = next
+		g.Pacman.NextMove = utils.Direction{}
+	}
+	dir := g.Pacman.Move
+	if dir == (utils.Direction{}) {
+		return
+	}
+	to := utils.Point{X: g.tunnelMove(g.Pacman.Position.X + dir.X), Y: g.Pacman.Position.Y + dir.Y}
+	if !g.canPacmanMove(to.X, to.Y) {
+		return
+	}
+	g.Pacman.Position = to
+
+	// Check for dot collection
+	if g.Grid.At(to.X, to.Y) == Dot {
+		g.Grid.Set(to.X, to.Y, Empty)
+		g.Score++
+		g.DotsEaten++
+		g.DotsLeft--
+		g.IdleTimer = 0
+		g.emit(DotEaten, "", 1)
+	}
+
+	// Check for win condition
+	if g.DotsLeft == 0 {
+		g.Won = true
+		g.emit(LevelWon, "", 0)
+		return
+	}
+
+	// Check for energizer collection
+	if g.Grid.At(to.X, to.Y) == Energizer {
+		g.Grid.Set(to.X, to.Y, Empty)
+
+		// Activate rampant mode
+		g.Pacman.RampantState = true
+		g.Pacman.CooldownState = false
+		g.RampantTimer = Seconds(g.Difficulty.RampantDuration)
+		g.GhostsEaten = 0
+		for i := range g.Ghosts {
+			if g.Ghosts[i].State != Eyes {
+				g.Ghosts[i].Frightened = true
+				g.Ghosts[i].Reverse = true
+			}
+		}
+		g.emit(EnergizerEaten, "", 0)
+	}
+	g.checkFruit()
+	g.checkGhostCollisions()
+}
+
+func (g *Game) moveGhosts() {
+	for i := range g.Ghosts {
+		ghost := &g.Ghosts[i]
+		credit := ghost.Speed * max(1, g.Difficulty.GhostSpeed)
+		if ghost.State == Eyes {
+			credit *= 2 // Eyes move twice as fast as ghosts
+		}
+		for ghost.MoveCredit += credit; ghost.MoveCredit >= moveCost; ghost.MoveCredit -= moveCost {
+			switch ghost.State {
+			case Active:
+				g.moveGhost(ghost)
+			case Leaving:
+				g.moveLeavingGhost(ghost)
+			case Eyes:
+				g.moveEyes(ghost)
+			}
+		}
+	}
+}
+
+// Check for collision with ghosts
+func (g *Game) checkGhostCollisions() {
+	for i := range g.Ghosts {
+		ghost := &g.Ghosts[i]
+		if ghost.State == Eyes || g.Pacman.Position != ghost.Position {
+			continue
+		}
+		if ghost.Frightened {
+			ghost.State = Eyes
+			ghost.Frightened = false
+			g.GhostsEaten++
+			points := ghostBonus * g.GhostsEaten
+			g.Score += points
+			g.emit(GhostEaten, ghost.Name, points)
+			continue
+		}
+		g.Lost = true
+		g.emit(PacmanDied, ghost.Name, 0)
+		return
+	}
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/replay"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Most steps played in a test game, Pac-Man of the random player is caught long before
+const testSteps = 3000
+
+var testDirections = []utils.Direction{{X: 0, Y: -1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 1, Y: 0}}
+
+// Start the level of the default configuration with the seed
+func newTestGame(t *testing.T, cfg config.Config, level config.Level, seed uint64) *Game {
+	t.Helper()
+	ghosts, err := cfg.LevelGhosts(level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(level, cfg.Difficulties[level.DifficultyName], ghosts, utils.NewRand(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// Input of a player who turns at random every few steps
+func randomInput(player *utils.Rand) Input {
+	if player.IntN(8) > 0 {
+		return Input{}
+	}
+	return Input{Dir: testDirections[player.IntN(len(testDirections))]}
+}
+
+// Play the game with the inputs of the player and return the events of every step
+func play(t *testing.T, g *Game, player *utils.Rand, steps int) [][]Event {
+	t.Helper()
+	var events [][]Event
+	outside := make([]int, len(g.Ghosts)) // Steps the ghosts have been leaving outside the house
+	for range steps {
+		if g.Over() {
+			break
+		}
+		events = append(events, g.Step(randomInput(player)))
+		for i, ghost := range g.Ghosts {
+			if ghost.State == Leaving && !g.inHouse(ghost.Position) && !g.isDoor(ghost.Position.X, ghost.Position.Y) {
+				outside[i]++
+			} else {
+				outside[i] = 0
+			}
+			if outside[i] > Seconds(1) {
+				t.Fatalf("%s, tick %d: %s is still leaving at %v outside the house", g.Level.Name, g.Tick, ghost.Name, ghost.Position)
+			}
+		}
+	}
+	return events
+}
+
+// Copy the snapshot the way it is saved to disk, so that it shares nothing with the game
+func saveSnapshot(t *testing.T, s Snapshot) Snapshot {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Snapshot
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved
+}
+
+// Games of the same level, seed and inputs are the same step by step
+func TestStepDeterministic(t *testing.T) {
+	cfg := config.Load()
+	for _, level := range cfg.Levels {
+		for seed := uint64(1); seed <= 5; seed++ {
+			a, b := newTestGame(t, cfg, level, seed), newTestGame(t, cfg, level, seed)
+			eventsA := play(t, a, utils.NewRand(seed), testSteps)
+			eventsB := play(t, b, utils.NewRand(seed), testSteps)
+			if !reflect.DeepEqual(eventsA, eventsB) {
+				t.Fatalf("%s, seed %d: the games have different events", level.Name, seed)
+			}
+			if !reflect.DeepEqual(a.Snapshot(), b.Snapshot()) {
+				t.Fatalf("%s, seed %d: the games end in different states", level.Name, seed)
+			}
+		}
+	}
+}
+
+// The score is the sum of the points of the events and dots are eaten one by one
+func TestStepEvents(t *testing.T) {
+	cfg := config.Load()
+	for _, level := range cfg.Levels {
+		for seed := uint64(1); seed <= 5; seed++ {
+			g := newTestGame(t, cfg, level, seed)
+			dots := g.DotsLeft
+			points, eaten := 0, 0
+			for _, events := range play(t, g, utils.NewRand(seed), testSteps) {
+				for _, e := range events {
+					points += e.Points
+					if e.Type == DotEaten {
+						eaten++
+					}
+				}
+			}
+			if points != g.Score {
+				t.Errorf("%s, seed %d: the events score %d points, the game %d", level.Name, seed, points, g.Score)
+			}
+			if eaten == 0 || eaten != g.DotsEaten || dots-eaten != g.DotsLeft {
+				t.Errorf("%s, seed %d: %d dots eaten, the game counts %d eaten and %d of %d left", level.Name, seed, eaten, g.DotsEaten, g.DotsLeft, dots)
+			}
+		}
+	}
+}
+
+// A restored game goes on exactly as the game it was saved from
+func TestRestore(t *testing.T) {
+	cfg := config.Load()
+	for _, level := range cfg.Levels {
+		for seed := uint64(1); seed <= 5; seed++ {
+			whole := newTestGame(t, cfg, level, seed)
+			play(t, whole, utils.NewRand(seed), testSteps)
+
+			// Save the same game halfway through and go on with the restored one
+			g := newTestGame(t, cfg, level, seed)
+			player := utils.NewRand(seed)
+			play(t, g, player, whole.Tick/2)
+			rng, err := g.Rng.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ghosts, err := cfg.LevelGhosts(level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			restoredRng := utils.NewRand(seed)
+			restored, err := Restore(level, ghosts, restoredRng, saveSnapshot(t, g.Snapshot()))
+			if err != nil {
+				t.Fatalf("%s, seed %d: %v", level.Name, seed, err)
+			}
+			if err := restoredRng.UnmarshalBinary(rng); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(g.Snapshot(), restored.Snapshot()) {
+				t.Fatalf("%s, seed %d: the restored game differs from the saved one", level.Name, seed)
+			}
+			play(t, restored, player, testSteps)
+			if !reflect.DeepEqual(whole.Snapshot(), restored.Snapshot()) {
+				t.Fatalf("%s, seed %d: the restored game went on differently", level.Name, seed)
+			}
+		}
+	}
+}
+
+// Playing the recorded inputs of a game back from its seed gives the same game
+func TestReplayPlayback(t *testing.T) {
+	cfg := config.Load()
+	for _, level := range cfg.Levels {
+		for seed := uint64(1); seed <= 5; seed++ {
+			g := newTestGame(t, cfg, level, seed)
+			r := replay.New(seed, config.Hash(cfg), level.Name)
+			player := utils.NewRand(seed)
+			for step := 0; step < testSteps && !g.Over(); step++ {
+				in := randomInput(player)
+				if in.Dir != (utils.Direction{}) {
+					r.Record(step, in.Dir)
+				}
+				g.Step(in)
+			}
+			r.Steps = g.Tick
+
+			data, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var loaded replay.Replay
+			if err := json.Unmarshal(data, &loaded); err != nil {
+				t.Fatal(err)
+			}
+			playback := newTestGame(t, cfg, level, loaded.Seed)
+			for step := 0; step < loaded.Steps; step++ {
+				playback.Step(Input{Dir: loaded.InputAt(step)})
+			}
+			if !reflect.DeepEqual(g.Snapshot(), playback.Snapshot()) {
+				t.Fatalf("%s, seed %d: the playback differs from the recorded game", level.Name, seed)
+			}
+		}
+	}
+}
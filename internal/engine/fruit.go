@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Seconds uneaten fruit stays in the maze if the level has no timeout
+const defaultFruitTimeout = 10
+
+// Bonus fruit that appears for a while when enough dots are eaten
+type Fruit struct {
+	Entity
+	Kind   int // Index of the fruit in the fruit table of the level
+	Points int
+	Timer  int // Ticks left before the fruit vanishes
+}
+
+// Find the cell of the level where fruit appears
+func (g *Game) findFruitCell() (utils.Point, error) {
+	position := g.Level.Fruit.Position
+	if len(position) == 0 {
+		return g.Pacman.Position, nil
+	}
+	if len(position) != 2 {
+		return utils.Point{}, fmt.Errorf("the fruit position must be a column and a row, got %v", position)
+	}
+	cell := utils.Point{X: position[0], Y: position[1]}
+	if !g.Grid.Inside(cell.X, cell.Y) || !g.canPacmanMove(cell.X, cell.Y) || g.inHouse(cell) {
+		return utils.Point{}, fmt.Errorf("the fruit position %v is not a free maze cell", position)
+	}
+	return cell, nil
+}
+
+// Show the next fruit of the level when enough dots are eaten and remove it when its time is up
+func (g *Game) updateFruit() {
+	if g.Fruit != nil {
+		if g.Fruit.Timer--; g.Fruit.Timer <= 0 {
+			g.Fruit = nil
+		}
+		return
+	}
+	settings := g.Level.Fruit
+	if len(settings.Table) == 0 || g.FruitsShown >= len(settings.Dots) || g.DotsEaten < settings.Dots[g.FruitsShown] {
+		return
+	}
+	kind := min(g.FruitsShown, len(settings.Table)-1)
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = defaultFruitTimeout
+	}
+	g.Fruit = &Fruit{
+		Entity: Entity{
+			Position: g.FruitCell,
+			Name:     settings.Table[kind].Name,
+		},
+		Kind:   kind,
+		Points: settings.Table[kind].Points,
+		Timer:  Seconds(timeout),
+	}
+	g.FruitsShown++
+}
+
+// Check if Pac-Man eats the fruit
+func (g *Game) checkFruit() {
+	if g.Fruit == nil || g.Pacman.Position != g.Fruit.Position {
+		return
+	}
+	g.Score += g.Fruit.Points
+	g.events = append(g.events, Event{Type: FruitEaten, Name: g.Fruit.Name, Points: g.Fruit.Points, Kind: g.Fruit.Kind})
+	g.Fruit = nil
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Maximum number of cached distance fields. Targets like Pac-Man's position change
+// all the time, so the cache is simply dropped when it is full.
+const maxCachedFields = 512
+
+const unreachable = math.MaxInt32
+
+// Graph of the maze cells built once per level. Shortest path distances
+// to a target are found with BFS over the whole maze and cached by target,
+// so every ghost heading to the same target in the same tick shares the work.
+type mazeGraph struct {
+	width, height int
+	open          []bool // Cells that are not walls
+	doors         []bool // Ghost house door cells
+	fields        map[fieldKey][]int32
+	starts        map[utils.Point]int // Open cells closest to targets
+}
+
+type fieldKey struct {
+	target      int
+	throughDoor bool
+}
+
+var graphDirections = []utils.Point{{X: 0, Y: -1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 1, Y: 0}}
+
+func newGraph(g *Game) *mazeGraph {
+	height := g.Grid.Height
+	width := g.Grid.Width
+	graph := &mazeGraph{
+		width:  width,
+		height: height,
+		open:   make([]bool, width*height),
+		doors:  make([]bool, width*height),
+		fields: make(map[fieldKey][]int32),
+		starts: make(map[utils.Point]int),
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			graph.open[y*width+x] = g.canMove(x, y)
+			graph.doors[y*width+x] = g.isDoor(x, y)
+		}
+	}
+	return graph
+}
+
+// Index of the cell the step from p leads to, wrapping through side tunnels, or -1 if it is off the maze
+func (gr *mazeGraph) neighbour(p, dir utils.Point) int {
+	x, y := p.X+dir.X, p.Y+dir.Y
+	if y < 0 || y >= gr.height {
+		return -1
+	}
+	x = (x + gr.width) % gr.width
+	return y*gr.width + x
+}
+
+func (gr *mazeGraph) passable(i int, throughDoor bool) bool {
+	return i >= 0 && gr.open[i] && (throughDoor || !gr.doors[i])
+}
+
+// Directions from p sorted by the length of the shortest path to the target.
+// Directions of equal length keep the order of straight distance to the target.
+func (gr *mazeGraph) directions(p, target utils.Point, throughDoor bool) []utils.Point {
+	field := gr.field(target, throughDoor)
+	directions := utils.SortDirectionsByDistance(p, target)
+	length := func(dir utils.Point) int32 {
+		if i := gr.neighbour(p, dir); gr.passable(i, throughDoor) {
+			return field[i]
+		}
+		return unreachable
+	}
+	sort.SliceStable(directions, func(i, j int) bool { return length(directions[i]) < length(directions[j]) })
+	return directions
+}
+
+// Distances of all cells to the target, computed by BFS from the target
+func (gr *mazeGraph) field(target utils.Point, throughDoor bool) []int32 {
+	start := gr.nearestOpen(target)
+	key := fieldKey{target: start, throughDoor: throughDoor}
+	if field, ok := gr.fields[key]; ok {
+		return field
+	}
+	field := make([]int32, len(gr.open))
+	for i := range field {
+		field[i] = unreachable
+	}
+	field[start] = 0
+	queue := []int{start}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		p := utils.Point{X: i % gr.width, Y: i / gr.width}
+		for _, dir := range graphDirections {
+			n := gr.neighbour(p, dir)
+			if gr.passable(n, throughDoor) && field[n] == unreachable {
+				field[n] = field[i] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	if len(gr.fields) >= maxCachedFields {
+		clear(gr.fields)
+		clear(gr.starts)
+	}
+	gr.fields[key] = field
+	return field
+}
+
+// Index of the open cell closest to the target, which may be a wall or off the maze
+func (gr *mazeGraph) nearestOpen(target utils.Point) int {
+	if target.X >= 0 && target.X < gr.width && target.Y >= 0 && target.Y < gr.height && gr.open[target.Y*gr.width+target.X] {
+		return target.Y*gr.width + target.X
+	}
+	if start, ok := gr.starts[target]; ok {
+		return start
+	}
+	best, bestDist := 0, math.MaxInt
+	for i, open := range gr.open {
+		if !open || gr.doors[i] {
+			continue
+		}
+		dx, dy := i%gr.width-target.X, i/gr.width-target.Y
+		if d := dx*dx + dy*dy; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	gr.starts[target] = best
+	return best
+}
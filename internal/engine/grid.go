@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Tile is the kind of a maze cell
+type Tile uint8
+
+const (
+	Empty     Tile = iota // Open cell
+	Wall                  // Nobody passes walls
+	Dot                   // Open cell with a dot to eat
+	Energizer             // Open cell with an energizer that makes ghosts frightened
+	Door                  // Ghost house door, only ghosts leaving or returning to the house pass it
+	Tunnel                // Open cell on the side of the maze leading to the opposite side
+)
+
+// Maze characters of the tiles in the level configuration and snapshots
+var tileChars = map[Tile]rune{Empty: ' ', Wall: '#', Dot: '.', Energizer: 'o', Door: '-', Tunnel: '='}
+
+// Grid is the maze of the level as tiles. It is the only source of truth about
+// the maze for the game rules, drawing the maze is left to front ends.
+type Grid struct {
+	Width, Height int
+	Tiles         []Tile // Rows of tiles one after another
+}
+
+// Parse the maze of the level. Pac-Man and ghost markers stand on dots.
+func newGrid(maze []string, markers map[rune]config.Ghost) (Grid, error) {
+	if len(maze) == 0 {
+		return Grid{}, fmt.Errorf("the maze is empty")
+	}
+	gr := Grid{Width: len([]rune(maze[0])), Height: len(maze)}
+	gr.Tiles = make([]Tile, gr.Width*gr.Height)
+	for y, row := range maze {
+		runes := []rune(row)
+		if len(runes) != gr.Width {
+			return Grid{}, fmt.Errorf("maze row %d is %d cells wide, the first row is %d", y+1, len(runes), gr.Width)
+		}
+		for x, char := range runes {
+			_, marker := markers[char]
+			tile := Empty
+			switch {
+			case char == '#':
+				tile = Wall
+			case char == doorChar:
+				tile = Door
+			case char == '.' || char == 'C' || marker:
+				tile = Dot
+			case char == 'o':
+				tile = Energizer
+			}
+			gr.Set(x, y, tile)
+		}
+	}
+	// A wall on one side of a tunnel is opened so that both ends lead somewhere
+	for y := 0; y < gr.Height; y++ {
+		left, right := gr.At(0, y), gr.At(gr.Width-1, y)
+		switch {
+		case left != Wall && right == Wall:
+			gr.Set(gr.Width-1, y, Empty)
+		case left == Wall && right != Wall:
+			gr.Set(0, y, Empty)
+		}
+		for _, x := range []int{0, gr.Width - 1} {
+			if gr.At(x, y) == Empty {
+				gr.Set(x, y, Tunnel)
+			}
+		}
+	}
+	return gr, nil
+}
+
+// Check if the cell is in the maze
+func (gr Grid) Inside(x, y int) bool {
+	return x >= 0 && x < gr.Width && y >= 0 && y < gr.Height
+}
+
+// At returns the tile of the cell, cells out of the maze are walls
+func (gr Grid) At(x, y int) Tile {
+	if !gr.Inside(x, y) {
+		return Wall
+	}
+	return gr.Tiles[y*gr.Width+x]
+}
+
+// Set changes the tile of the cell
+func (gr Grid) Set(x, y int, tile Tile) {
+	if gr.Inside(x, y) {
+		gr.Tiles[y*gr.Width+x] = tile
+	}
+}
+
+// Count the tiles of the kind
+func (gr Grid) Count(tile Tile) int {
+	n := 0
+	for _, t := range gr.Tiles {
+		if t == tile {
+			n++
+		}
+	}
+	return n
+}
+
+// Cells of the grid with the tiles in row order
+func (gr Grid) cells(tiles ...Tile) []utils.Point {
+	var points []utils.Point
+	for i, t := range gr.Tiles {
+		for _, tile := range tiles {
+			if t == tile {
+				points = append(points, utils.Point{X: i % gr.Width, Y: i / gr.Width})
+				break
+			}
+		}
+	}
+	return points
+}
+
+// MarshalText writes the grid as rows of tile characters
+func (gr Grid) MarshalText() ([]byte, error) {
+	var b strings.Builder
+	for y := 0; y < gr.Height; y++ {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		for x := 0; x < gr.Width; x++ {
+			b.WriteRune(tileChars[gr.At(x, y)])
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText reads the grid written by MarshalText
+func (gr *Grid) UnmarshalText(text []byte) error {
+	rows := strings.Split(string(text), "\n")
+	g := Grid{Width: len([]rune(rows[0])), Height: len(rows)}
+	g.Tiles = make([]Tile, 0, g.Width*g.Height)
+	for y, row := range rows {
+		if len([]rune(row)) != g.Width {
+			return fmt.Errorf("grid row %d is %d cells wide, the first row is %d", y+1, len([]rune(row)), g.Width)
+		}
+	row:
+		for _, char := range row {
+			for tile, c := range tileChars {
+				if c == char {
+					g.Tiles = append(g.Tiles, tile)
+					continue row
+				}
+			}
+			return fmt.Errorf("unknown tile %q in grid row %d", char, y+1)
+		}
+	}
+	*gr = g
+	return nil
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Maze character of the ghost house door
+const doorChar = '-'
+
+type GhostState int
+
+const (
+	Active  GhostState = iota // Roaming the maze
+	InHouse                   // Waiting in the ghost house to be released
+	Leaving                   // Heading out of the ghost house through the door
+	Eyes                      // Eaten and returning to the ghost house to revive
+)
+
+// Ghost house of the maze. Mazes without a door have no house and ghosts
+// wait and revive at their starting points instead.
+type House struct {
+	Cells  map[utils.Point]bool // Cells inside the house
+	Inside utils.Point          // Cell just inside the door
+	Exit   utils.Point          // Cell just outside the door
+}
+
+// Find the ghost house behind the first door of the maze. The inside of the house
+// is the smaller of the two areas separated by the door.
+func findHouse(grid Grid) *House {
+	for _, door := range grid.cells(Door) {
+		for _, dir := range []utils.Point{{X: 0, Y: 1}, {X: 1, Y: 0}} {
+			a := utils.Point{X: door.X + dir.X, Y: door.Y + dir.Y}
+			b := utils.Point{X: door.X - dir.X, Y: door.Y - dir.Y}
+			if !isOpen(grid, a) || !isOpen(grid, b) {
+				continue
+			}
+			areaA, areaB := floodFill(grid, a), floodFill(grid, b)
+			if len(areaA) > len(areaB) {
+				a, b, areaA = b, a, areaB
+			}
+			if areaA[b] {
+				continue // Both sides are connected, this is not a house door
+			}
+			return &House{Cells: areaA, Inside: a, Exit: b}
+		}
+	}
+	return nil
+}
+
+// Check if the maze cell is neither a wall nor a door
+func isOpen(grid Grid, p utils.Point) bool {
+	tile := grid.At(p.X, p.Y)
+	return tile != Wall && tile != Door
+}
+
+// Cells reachable from the start without passing walls and doors
+func floodFill(grid Grid, start utils.Point) map[utils.Point]bool {
+	area := map[utils.Point]bool{start: true}
+	queue := []utils.Point{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, dir := range []utils.Point{{X: 0, Y: -1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 1, Y: 0}} {
+			next := utils.Point{X: p.X + dir.X, Y: p.Y + dir.Y}
+			if !area[next] && isOpen(grid, next) {
+				area[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return area
+}
+
+// Check if the cell is inside the ghost house
+func (g *Game) inHouse(p utils.Point) bool {
+	return g.House != nil && g.House.Cells[p]
+}
+
+// Release ghosts waiting in the house when enough dots are eaten, or the next
+// waiting ghost when Pac-Man has not eaten a dot for a while
+func (g *Game) updateHouse() {
+	g.IdleTimer++
+	var waiting []*Ghost
+	for i := range g.Ghosts {
+		ghost := &g.Ghosts[i]
+		if ghost.State != InHouse {
+			continue
+		}
+		if ghost.HouseTimer > 0 {
+			ghost.HouseTimer--
+			continue
+		}
+		if g.DotsEaten >= ghost.ReleaseDots {
+			g.releaseGhost(ghost)
+			continue
+		}
+		waiting = append(waiting, ghost)
+	}
+	if timer := Seconds(g.Difficulty.GhostRelease.Timer); timer > 0 && g.IdleTimer >= timer && len(waiting) > 0 {
+		sort.SliceStable(waiting, func(i, j int) bool { return waiting[i].ReleaseDots < waiting[j].ReleaseDots })
+		g.releaseGhost(waiting[0])
+		g.IdleTimer = 0
+	}
+}
+
+func (g *Game) releaseGhost(ghost *Ghost) {
+	ghost.State = Active
+	if g.House != nil {
+		ghost.State = Leaving
+	}
+}
+
+// Move the ghost leaving the house towards the door exit. The ghost is out as soon as
+// it is past the door, wide doors may let it out next to the exit rather than on it.
+// Ghosts always find the shortest way out and back home whatever the navigation.
+func (g *Game) moveLeavingGhost(ghost *Ghost) {
+	g.ghostMove(ghost, g.graph.directions(ghost.Position, g.House.Exit, true))
+	if pos := ghost.Position; !g.inHouse(pos) && !g.isDoor(pos.X, pos.Y) {
+		ghost.State = Active
+	}
+}
+
+// Move the eyes of the eaten ghost back to the house, where the ghost revives
+func (g *Game) moveEyes(ghost *Ghost) {
+	target := ghost.RevivalPoint
+	if g.House != nil {
+		target = g.House.Inside
+	}
+	g.ghostMove(ghost, g.graph.directions(ghost.Position, target, true))
+	if ghost.Position == target {
+		// Revive the ghost and keep it in the house for a while
+		ghost.State = InHouse
+		ghost.Move = utils.Direction{}
+		ghost.ReleaseDots = 0
+		ghost.HouseTimer = Seconds(g.Difficulty.RevivalTimer)
+	}
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Ghosts released through a door three cells wide must become active as soon as they are out
+func TestLeaveWideDoor(t *testing.T) {
+	level := config.Level{
+		Name: "Wide door",
+		Maze: []string{
+			"###############",
+			"#......C......#",
+			"#.####---####.#",
+			"#.#  B I P  #.#",
+			"#.###########.#",
+			"#.............#",
+			"###############",
+		},
+	}
+	ghosts := config.DefaultGhosts[:3]
+	for seed := uint64(1); seed <= 10; seed++ {
+		g, err := New(level, config.Difficulty{PacmanSpeed: 5, GhostSpeed: 4}, ghosts, utils.NewRand(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range Seconds(10) {
+			g.Step(Input{})
+			for _, ghost := range g.Ghosts {
+				if ghost.State == Leaving && !g.inHouse(ghost.Position) && !g.isDoor(ghost.Position.X, ghost.Position.Y) {
+					t.Fatalf("seed %d, tick %d: %s is still leaving at %v outside the house", seed, g.Tick, ghost.Name, ghost.Position)
+				}
+			}
+			if g.Over() {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Letters that can't be ghost markers, 'c' is the chewing Pac-Man on screen
+const reservedMarkers = "Cco"
+
+// Check the ghost markers and index the ghosts by them
+func ghostMarkers(ghosts []config.Ghost) (map[rune]config.Ghost, error) {
+	if len(ghosts) == 0 {
+		return nil, errors.New("the level must have at least one ghost")
+	}
+	markers := make(map[rune]config.Ghost, len(ghosts))
+	for _, ghost := range ghosts {
+		id := []rune(ghost.ID)
+		if len(id) != 1 || !unicode.IsLetter(id[0]) || strings.ContainsRune(reservedMarkers, id[0]) {
+			return nil, fmt.Errorf("ghost %s must have a single letter id other than %q", ghost.Name, reservedMarkers)
+		}
+		if _, ok := markers[id[0]]; ok {
+			return nil, fmt.Errorf("ghost id %s is used twice", ghost.ID)
+		}
+		markers[id[0]] = ghost
+	}
+	return markers, nil
+}
+
+// Place Pac-Man and ghosts from the maze markers
+func (g *Game) placeEntities(maze []string, ghosts []config.Ghost, markers map[rune]config.Ghost) {
+	pacmanPlaced := false
+	ghostsPlaced := make(map[string]bool)
+	var definitions []config.Ghost // Definitions of the ghosts in the order they are placed
+
+	for y, row := range maze {
+		for x, char := range []rune(row) {
+			if char == 'C' {
+				g.Pacman = initPacmanAt(utils.Point{X: x, Y: y})
+				pacmanPlaced = true
+				continue
+			}
+			ghost, ok := markers[char]
+			if !ok || ghostsPlaced[ghost.ID] {
+				continue
+			}
+			g.Ghosts = append(g.Ghosts, initGhostAt(utils.Point{X: x, Y: y}, ghost.Name, char))
+			definitions = append(definitions, ghost)
+			ghostsPlaced[ghost.ID] = true
+		}
+	}
+
+	// Randomly place the pacman from edges to center if not placed
+	if !pacmanPlaced {
+		g.Pacman = g.placePacmanRandomly(maze, markers)
+	}
+
+	// Randomly place ghosts near the center if not placed
+	for _, ghost := range ghosts {
+		if !ghostsPlaced[ghost.ID] {
+			g.Ghosts = append(g.Ghosts, g.placeGhostRandomly(maze, markers, ghost.Name, []rune(ghost.ID)[0]))
+			definitions = append(definitions, ghost)
+		}
+	}
+
+	// Send ghosts to their home corners in scatter mode and keep them in the house until released
+	for i := range g.Ghosts {
+		ghost := &g.Ghosts[i]
+		ghost.Home = homeCorner(definitions[i].Home, i, g.Grid.Width, g.Grid.Height)
+		ghost.Speed = 100
+		if definitions[i].Speed > 0 {
+			ghost.Speed = int(math.Round(definitions[i].Speed * 100))
+		}
+		ghost.Strategy = definitions[i].Strategy
+		ghost.State = InHouse
+		ghost.ReleaseDots = g.Difficulty.GhostRelease.Dots[ghost.Name]
+	}
+}
+
+// Corners of the maze in the order they are given to ghosts without a home
+var homeCorners = []string{config.TopRight, config.TopLeft, config.BottomRight, config.BottomLeft}
+
+// Home corner of the ghost, the i-th ghost without a home gets the i-th corner in turn
+func homeCorner(home string, i, width, height int) utils.Point {
+	if home == "" {
+		home = homeCorners[i%len(homeCorners)]
+	}
+	switch home {
+	case config.TopRight:
+		return utils.Point{X: width - 1, Y: 0}
+	case config.BottomRight:
+		return utils.Point{X: width - 1, Y: height - 1}
+	case config.BottomLeft:
+		return utils.Point{X: 0, Y: height - 1}
+	}
+	return utils.Point{X: 0, Y: 0}
+}
+
+func initPacmanAt(pos utils.Point) Pacman {
+	return Pacman{
+		Entity: Entity{
+			Position: pos,
+			Name:     "Pac-Man",
+			Badge:    'C',
+		},
+	}
+}
+
+func initGhostAt(pos utils.Point, name string, badge rune) Ghost {
+	return Ghost{
+		Entity: Entity{
+			Position: pos,
+			Name:     name,
+			Badge:    badge,
+		},
+		RevivalPoint: pos,
+	}
+}
+
+func (g *Game) placePacmanRandomly(maze []string, markers map[rune]config.Ghost) Pacman {
+	pos := utils.Point{}
+	free := utils.TraverseOrder(g.freeCells(maze, markers), g.Grid.Width, g.Grid.Height, utils.MazePerifery)
+	if len(free) > 0 {
+		pos = free[g.Rng.IntN(min(4, len(free)))]
+	}
+	return initPacmanAt(pos)
+
+}
+
+func (g *Game) placeGhostRandomly(maze []string, markers map[rune]config.Ghost, name string, badge rune) Ghost {
+	pos := utils.Point{}
+	var free []utils.Point
+	for _, p := range utils.TraverseOrder(g.freeCells(maze, markers), g.Grid.Width, g.Grid.Height, utils.MazeCenter) {
+		if !g.isGhostHere(p) {
+			free = append(free, p)
+		}
+	}
+	if len(free) > 0 {
+		pos = free[g.Rng.IntN(min(6, len(free)))]
+	}
+	return initGhostAt(pos, name, badge)
+
+}
+
+// Open cells without energizers and markers, where Pac-Man and ghosts may be placed at random
+func (g *Game) freeCells(maze []string, markers map[rune]config.Ghost) []utils.Point {
+	var free []utils.Point
+	for y, row := range maze {
+		for x, char := range []rune(row) {
+			if _, ok := markers[char]; ok || char == 'C' {
+				continue
+			}
+			if tile := g.Grid.At(x, y); tile != Wall && tile != Door && tile != Energizer {
+				free = append(free, utils.Point{X: x, Y: y})
+			}
+		}
+	}
+	return free
+}
+
+func (g *Game) tunnelMove(newX int) int {
+	if newX < 0 {
+		return g.Grid.Width - 1
+	}
+	if newX >= g.Grid.Width {
+		return 0
+	}
+	return newX
+}
+
+// Check if the cell is the ghost house door
+func (g *Game) isDoor(x, y int) bool {
+	return g.Grid.At(x, y) == Door
+}
+
+// Check if Pac-Man can move to the cell
+func (g *Game) canPacmanMove(x, y int) bool {
+	return g.canMove(x, y) && !g.isDoor(x, y)
+}
+
+// Check if movement is possible
+func (g *Game) canMove(x, y int) bool {
+	return g.Grid.At(x, y) != Wall
+}
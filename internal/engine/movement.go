@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+type GhostMode int
+
+const (
+	Scatter GhostMode = iota // Ghosts head for their home corners
+	Chase                    // Ghosts hunt Pac-Man, each in its own way
+)
+
+// Move the ghost one cell according to its mode
+func (g *Game) moveGhost(ghost *Ghost) {
+	var directions []utils.Point
+	switch {
+	case ghost.Frightened:
+		directions = utils.RandomDirections(g.Rng)
+	case g.Mode == Scatter:
+		directions = g.DirectionsTo(ghost.Position, ghost.Home)
+	default:
+		directions = strategies[ghost.Strategy].Directions(g, ghost)
+	}
+	g.ghostMove(ghost, directions)
+}
+
+// DirectionsTo returns directions to the target in order of preference, by straight
+// distance as in the arcade or by the shortest path through the maze as the difficulty says
+func (g *Game) DirectionsTo(from, target utils.Point) []utils.Point {
+	if g.Difficulty.Navigation == config.PathfindingNavigation {
+		return g.graph.directions(from, target, false)
+	}
+	return utils.SortDirectionsByDistance(from, target)
+}
+
+// Move the ghost to the first possible direction. Ghosts do not turn back
+// unless they are in a dead end or have been told to reverse.
+func (g *Game) ghostMove(ghost *Ghost, directions []utils.Point) {
+	back := utils.Point{X: -ghost.Move.X, Y: -ghost.Move.Y}
+	if ghost.Reverse {
+		ghost.Reverse = false
+		if ghost.Move != (utils.Direction{}) && g.tryGhostMove(ghost, back) {
+			return
+		}
+	}
+	for _, dir := range directions {
+		if dir == back {
+			continue
+		}
+		if g.tryGhostMove(ghost, dir) {
+			return
+		}
+	}
+	g.tryGhostMove(ghost, back)
+}
+
+func (g *Game) tryGhostMove(ghost *Ghost, dir utils.Point) bool {
+	to := utils.Point{X: g.tunnelMove(ghost.Position.X + dir.X), Y: ghost.Position.Y + dir.Y}
+	if !g.canMove(to.X, to.Y) {
+		return false
+	}
+	if ghost.State != Eyes && g.isGhostHere(to) {
+		return false // Eyes pass through other ghosts
+	}
+	if g.isDoor(to.X, to.Y) && ghost.State != Leaving && ghost.State != Eyes {
+		return false
+	}
+	ghost.Position = to
+	ghost.Move = utils.Direction(dir)
+	return true
+}
+
+func (g *Game) isGhostHere(p utils.Point) bool {
+	for _, ghost := range g.Ghosts {
+		if ghost.State != Eyes && ghost.Position == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch between scatter and chase modes following the difficulty schedule
+func (g *Game) updateMode() {
+	if g.ModeTimer == 0 || g.Pacman.RampantState {
+		return // The last mode lasts forever and the schedule is paused while ghosts are frightened
+	}
+	if g.ModeTimer--; g.ModeTimer > 0 {
+		return
+	}
+	g.ModePhase++
+	g.Mode = modeOfPhase(g.ModePhase)
+	if g.ModePhase < len(g.Difficulty.ModeSchedule) {
+		g.ModeTimer = max(1, Seconds(g.Difficulty.ModeSchedule[g.ModePhase]))
+	}
+	g.reverseGhosts()
+}
+
+// Even phases of the schedule are scatter, odd phases are chase
+func modeOfPhase(phase int) GhostMode {
+	if phase%2 == 0 {
+		return Scatter
+	}
+	return Chase
+}
+
+func (g *Game) reverseGhosts() {
+	for i := range g.Ghosts {
+		g.Ghosts[i].Reverse = true
+	}
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Snapshot is the state of a game in progress. Together with the level, the ghosts
+// and the random number generator it is enough to restore the game.
+type Snapshot struct {
+	Difficulty    config.Difficulty // Difficulty the level was started with
+	Grid          Grid
+	Pacman        Pacman
+	Ghosts        []Ghost
+	Score         int
+	Tick          int
+	Won           bool
+	Lost          bool
+	GhostsEaten   int
+	RampantTimer  int
+	CooldownTimer int
+	Mode          GhostMode
+	ModePhase     int
+	ModeTimer     int
+	DotsEaten     int
+	DotsLeft      int
+	IdleTimer     int
+	Fruit         *Fruit
+	FruitsShown   int
+}
+
+// Snapshot returns the state of the game
+func (g *Game) Snapshot() Snapshot {
+	return Snapshot{
+		Difficulty:    g.Difficulty,
+		Grid:          g.Grid,
+		Pacman:        g.Pacman,
+		Ghosts:        g.Ghosts,
+		Score:         g.Score,
+		Tick:          g.Tick,
+		Won:           g.Won,
+		Lost:          g.Lost,
+		GhostsEaten:   g.GhostsEaten,
+		RampantTimer:  g.RampantTimer,
+		CooldownTimer: g.CooldownTimer,
+		Mode:          g.Mode,
+		ModePhase:     g.ModePhase,
+		ModeTimer:     g.ModeTimer,
+		DotsEaten:     g.DotsEaten,
+		DotsLeft:      g.DotsLeft,
+		IdleTimer:     g.IdleTimer,
+		Fruit:         g.Fruit,
+		FruitsShown:   g.FruitsShown,
+	}
+}
+
+// Restore returns the game of the level in the state of the snapshot.
+// The random number generator must be restored by the caller.
+func Restore(level config.Level, ghosts []config.Ghost, rng *utils.Rand, s Snapshot) (*Game, error) {
+	g, err := New(level, s.Difficulty, ghosts, rng)
+	if err != nil {
+		return nil, err
+	}
+	if s.Grid.Width != g.Grid.Width || s.Grid.Height != g.Grid.Height || len(s.Ghosts) != len(g.Ghosts) {
+		return nil, errors.New("the snapshot does not match the level")
+	}
+	for _, ghost := range s.Ghosts {
+		if _, ok := strategies[ghost.Strategy]; !ok {
+			return nil, fmt.Errorf("unknown strategy %q for %s in the snapshot", ghost.Strategy, ghost.Name)
+		}
+	}
+	g.Grid = s.Grid
+	g.Pacman = s.Pacman
+	g.Ghosts = s.Ghosts
+	g.Score = s.Score
+	g.Tick = s.Tick
+	g.Won = s.Won
+	g.Lost = s.Lost
+	g.GhostsEaten = s.GhostsEaten
+	g.RampantTimer = s.RampantTimer
+	g.CooldownTimer = s.CooldownTimer
+	g.Mode = s.Mode
+	g.ModePhase = s.ModePhase
+	g.ModeTimer = s.ModeTimer
+	g.DotsEaten = s.DotsEaten
+	g.DotsLeft = s.DotsLeft
+	g.IdleTimer = s.IdleTimer
+	g.Fruit = s.Fruit
+	g.FruitsShown = s.FruitsShown
+	return g, nil
+}
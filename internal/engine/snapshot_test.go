@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// A snapshot with an unknown ghost strategy must not restore into a game that panics on the next step
+func TestRestoreUnknownStrategy(t *testing.T) {
+	level := config.Level{
+		Name: "Box",
+		Maze: []string{
+			"#########",
+			"#...C...#",
+			"#.##-##.#",
+			"#.#B P#.#",
+			"#.#####.#",
+			"#.......#",
+			"#########",
+		},
+	}
+	ghosts := []config.Ghost{config.DefaultGhosts[0], config.DefaultGhosts[2]}
+	g, err := New(level, config.Difficulty{}, ghosts, utils.NewRand(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := g.Snapshot()
+	s.Ghosts = append([]Ghost(nil), s.Ghosts...)
+	s.Ghosts[1].Strategy = "teleport"
+	if _, err := Restore(level, ghosts, utils.NewRand(1), s); err == nil {
+		t.Fatal("restored a snapshot with an unknown strategy")
+	}
+	s.Ghosts[1].Strategy = ""
+	if _, err := Restore(level, ghosts, utils.NewRand(1), s); err == nil {
+		t.Fatal("restored a snapshot without a strategy")
+	}
+}
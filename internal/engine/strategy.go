@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// GhostStrategy is the personality of a ghost in chase mode
+type GhostStrategy interface {
+	// Directions returns the directions the chasing ghost prefers, best first.
+	// The ghost takes the first one it can move to without turning back.
+	Directions(g *Game, ghost *Ghost) []utils.Point
+}
+
+// StrategyFunc adapts a plain function to the GhostStrategy interface
+type StrategyFunc func(g *Game, ghost *Ghost) []utils.Point
+
+func (f StrategyFunc) Directions(g *Game, ghost *Ghost) []utils.Point {
+	return f(g, ghost)
+}
+
+var strategies = map[string]GhostStrategy{}
+
+// RegisterStrategy makes the strategy available to the configuration under the name
+func RegisterStrategy(name string, s GhostStrategy) {
+	strategies[name] = s
+}
+
+// Strategies returns the names of all registered strategies
+func Strategies() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Built-in strategies
+const (
+	ChaseStrategy  = "chase"  // Head straight for Pac-Man
+	AmbushStrategy = "ambush" // Head for the cell in front of Pac-Man
+	RandomStrategy = "random" // Wander around
+	CageyStrategy  = "cagey"  // Head for the cell behind Pac-Man
+	ShyStrategy    = "shy"    // Chase Pac-Man from afar but retreat home when close
+)
+
+// Shy ghosts retreat when they are closer to Pac-Man than this many cells
+const shyDistance = 8
+
+func init() {
+	RegisterStrategy(ChaseStrategy, StrategyFunc(func(g *Game, ghost *Ghost) []utils.Point {
+		return g.DirectionsTo(ghost.Position, g.Pacman.Position)
+	}))
+	RegisterStrategy(AmbushStrategy, StrategyFunc(func(g *Game, ghost *Ghost) []utils.Point {
+		destination := utils.Point{X: g.Pacman.Position.X + g.Pacman.Move.X, Y: g.Pacman.Position.Y + g.Pacman.Move.Y}
+		return g.DirectionsTo(ghost.Position, destination)
+	}))
+	RegisterStrategy(RandomStrategy, StrategyFunc(func(g *Game, ghost *Ghost) []utils.Point {
+		return utils.RandomDirections(g.Rng)
+	}))
+	RegisterStrategy(CageyStrategy, StrategyFunc(func(g *Game, ghost *Ghost) []utils.Point {
+		destination := utils.Point{X: g.Pacman.Position.X - 2*g.Pacman.Move.X, Y: g.Pacman.Position.Y - 2*g.Pacman.Move.Y}
+		return g.DirectionsTo(ghost.Position, destination)
+	}))
+	RegisterStrategy(ShyStrategy, StrategyFunc(func(g *Game, ghost *Ghost) []utils.Point {
+		dx, dy := ghost.Position.X-g.Pacman.Position.X, ghost.Position.Y-g.Pacman.Position.Y
+		if dx*dx+dy*dy < shyDistance*shyDistance {
+			return g.DirectionsTo(ghost.Position, ghost.Home)
+		}
+		return g.DirectionsTo(ghost.Position, g.Pacman.Position)
+	}))
+}
+
+// Assign strategies to ghosts: the level settings win over the difficulty ones,
+// which win over the ghost definitions
+func (g *Game) assignStrategies() error {
+	for i := range g.Ghosts {
+		ghost := &g.Ghosts[i]
+		name, ok := g.Level.Strategies[ghost.Name]
+		if !ok {
+			name, ok = g.Difficulty.Strategies[ghost.Name]
+		}
+		if !ok && ghost.Strategy != "" {
+			name = ghost.Strategy
+		} else if !ok {
+			name = ChaseStrategy
+		}
+		if _, ok := strategies[name]; !ok {
+			return fmt.Errorf("unknown strategy %q for %s, available strategies are %v", name, ghost.Name, Strategies())
+		}
+		ghost.Strategy = name
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/engine"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/ui"
+)
+
+// Longest player name in the leaderboard
+const maxPlayerNameLength = 12
+
+// Leaderboard of the game: the level pack played at the difficulty of the level the game started at
+func (m *Model) leaderboardKey() string {
+	return state.LeaderboardKey(config.Hash(m.Config), m.GameDifficulty)
+}
+
+// Ask for the player name if the score of the finished game makes it to the leaderboard
+func (m *Model) startNameEntry() {
+	m.ScoreRank = -1
+	if !m.Qualifies(m.leaderboardKey(), m.GameScore) {
+		return
+	}
+	m.NameEntry = true
+	m.PlayerName = m.State.PlayerName
+}
+
+// Edit the player name and put the score into the leaderboard when it is entered
+func (m *Model) updateNameEntry(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.Type {
+	case tea.KeyEsc:
+		m.NameEntry = false // Skip the leaderboard
+	case tea.KeyEnter:
+		if m.PlayerName == "" {
+			return m, nil
+		}
+		m.NameEntry = false
+		m.State.PlayerName = m.PlayerName
+		m.ScoreRank = m.AddScore(m.leaderboardKey(), state.Score{
+			Name:     m.PlayerName,
+			Score:    m.GameScore,
+			Level:    m.Levels[m.CurrentLevel].Name,
+			Duration: m.Steps / engine.TicksPerSecond,
+			Seed:     m.Rng.Seed,
+			Date:     time.Now(),
+		})
+		m.saveState()
+		m.ShowScores = true
+	case tea.KeyBackspace:
+		if name := []rune(m.PlayerName); len(name) > 0 {
+			m.PlayerName = string(name[:len(name)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		if len([]rune(m.PlayerName))+len(key.Runes) <= maxPlayerNameLength {
+			m.PlayerName += string(key.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) nameEntryView() string {
+	view := fmt.Sprintf("New high score: %d!\n", m.GameScore)
+	view += fmt.Sprintf("Enter your name: %s_\n", m.PlayerName)
+	view += "Press Enter to save, Esc to skip."
+	return view
+}
+
+// Leaderboard of the game, the score of the finished game is highlighted
+func (m *Model) highScoresView() string {
+	return leaderboardView(m.GameDifficulty, m.Leaderboards[m.leaderboardKey()], m.ScoreRank) + "\n\nPress 'h' to hide high scores."
+}
+
+// Leaderboard of the difficulty with the entry at the index highlighted
+func leaderboardView(difficulty string, board []state.Score, highlight int) string {
+	view := fmt.Sprintf("High scores, %s\n", difficulty)
+	if len(board) == 0 {
+		view += "\nNo scores yet"
+	}
+	for i, s := range board {
+		line := fmt.Sprintf("%2d. %-*s %7d  %-10s %4ds  %s  seed %d", i+1, maxPlayerNameLength, s.Name, s.Score, s.Level, s.Duration, s.Date.Format(time.DateOnly), s.Seed)
+		if i == highlight {
+			line = ui.FlashStyle.Render(line)
+		}
+		view += "\n" + line
+	}
+	return view
+}
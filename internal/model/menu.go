@@ -0,0 +1,272 @@
+package model
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/sound"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/ui"
+)
+
+// Entries of the main menu
+const (
+	newGameEntry     = "New game"
+	continueEntry    = "Continue"
+	levelSelectEntry = "Level select"
+	difficultyEntry  = "Difficulty"
+	themeEntry       = "Theme"
+	soundEntry       = "Sound"
+	controlsEntry    = "Controls"
+	highScoresEntry  = "High scores"
+	profilesEntry    = "Profiles"
+	quitEntry        = "Quit"
+)
+
+// Value of the settings that leave the choice to each level
+const levelDefault = "Level default"
+
+// Menu is the root model of the game. It shows the main menu and runs the screen
+// chosen there until the screen sends the player back to the menu.
+type Menu struct {
+	game   *Model
+	screen tea.Model // Screen opened from the menu, nil when the menu itself is shown
+	cursor int
+	splash bool // Show the splash screen until the game starts
+}
+
+// Message type for going back to the menu
+type backToMenuMsg struct{}
+
+// Command to go back to the menu
+func backToMenu() tea.Msg {
+	return backToMenuMsg{}
+}
+
+// Message type for starting a new game at the level
+type newGameMsg struct {
+	levelName string
+}
+
+// NewMenu returns the menu of the game. A zero seed means a random one for every game.
+func NewMenu(seed uint64) *Menu {
+	mn := &Menu{game: New(seed), splash: true}
+	if err := mn.game.loadSnapshot(); err != nil {
+		mn.game.warnings = append(mn.game.warnings, "The saved game could not be continued: "+err.Error())
+	}
+	mn.showWarnings()
+	return mn
+}
+
+func (mn *Menu) Init() tea.Cmd {
+	mn.game.playSound(sound.BEGINNING)
+	return splashScreen()
+}
+
+// Entries of the menu, the game can be continued unless it is over or not started
+func (mn *Menu) entries() []string {
+	entries := []string{newGameEntry}
+	if mn.game.inProgress() {
+		entries = append(entries, continueEntry)
+	}
+	return append(entries, levelSelectEntry, difficultyEntry, themeEntry, soundEntry, controlsEntry, highScoresEntry, profilesEntry, quitEntry)
+}
+
+func (mn *Menu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case startGameMsg:
+		mn.splash = false
+		return mn, nil
+	case backToMenuMsg:
+		mn.screen = nil
+		mn.cursor = 0
+		mn.game.saveState() // Keep the settings changed on the screen
+		mn.showWarnings()
+		return mn, nil
+	case newGameMsg:
+		return mn, mn.newGame(msg.levelName)
+	case tea.WindowSizeMsg:
+		mn.game.Update(msg) // The game keeps the size even when it is not shown
+		return mn, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return mn, mn.quit()
+		}
+	}
+	if mn.screen != nil {
+		var cmd tea.Cmd
+		mn.screen, cmd = mn.screen.Update(msg)
+		return mn, cmd
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return mn, nil
+	}
+	if mn.splash {
+		mn.splash = false
+		return mn, nil
+	}
+	entries := mn.entries()
+	switch key.String() {
+	case "up":
+		mn.cursor = (mn.cursor + len(entries) - 1) % len(entries)
+	case "down":
+		mn.cursor = (mn.cursor + 1) % len(entries)
+	case "enter", " ":
+		return mn, mn.open(entries[mn.cursor])
+	case "q", "esc":
+		return mn, mn.quit()
+	}
+	return mn, nil
+}
+
+// Open the screen of the menu entry
+func (mn *Menu) open(entry string) tea.Cmd {
+	g := mn.game
+	switch entry {
+	case newGameEntry:
+		return mn.newGame(g.Levels[0].Name)
+	case continueEntry:
+		mn.screen = g
+		return g.resume()
+	case levelSelectEntry:
+		names := make([]string, len(g.Levels))
+		for i, level := range g.Levels {
+			names[i] = level.Name
+		}
+		mn.screen = &listScreen{title: levelSelectEntry, items: names, choose: func(i int) tea.Cmd {
+			return func() tea.Msg { return newGameMsg{levelName: names[i]} }
+		}}
+	case difficultyEntry:
+		difficulties := append([]string{levelDefault}, slices.Sorted(maps.Keys(g.Difficulties))...)
+		mn.screen = &settingsScreen{title: difficultyEntry, settings: []setting{
+			newSetting("Difficulty", difficulties, g.State.Difficulty, func(value string) {
+				g.State.Difficulty = value
+			}),
+		}, footer: "The difficulty applies from the next game."}
+	case themeEntry:
+		pacmanStyles := append([]string{levelDefault}, slices.Sorted(maps.Keys(g.Badges.Pacman))...)
+		themes := append([]string{levelDefault}, slices.Sorted(maps.Keys(g.Themes))...)
+		mn.screen = &settingsScreen{title: themeEntry, settings: []setting{
+			newSetting("Theme", themes, g.State.Theme, func(value string) {
+				g.State.Theme = value
+				g.loadLooks()
+			}),
+			newSetting("Pac-Man badges", pacmanStyles, g.State.PacmanBadge, func(value string) {
+				g.State.PacmanBadge = value
+			}),
+			newSetting("Ghost badges", append([]string{levelDefault}, g.ghostBadgeStyles()...), g.State.GhostBadges, func(value string) {
+				g.State.GhostBadges = value
+				g.loadLooks()
+			}),
+			newSetting("Cells", []string{levelDefault, config.NarrowCells, config.SquareCells}, g.State.Cells, func(value string) {
+				g.State.Cells = value
+			}),
+			newSetting("Minimap", []string{"On", "Off"}, onOff(g.Minimap), func(value string) {
+				g.Minimap = value == "On"
+			}),
+		}, footer: "Square cells take two columns per tile. The minimap shows the whole maze when it does not fit the terminal."}
+	case soundEntry:
+		mn.screen = &settingsScreen{title: soundEntry, settings: []setting{
+			newSetting("Sound", []string{"On", "Off"}, onOff(!g.Mute), func(value string) {
+				g.Mute = value == "Off"
+			}),
+		}}
+	case controlsEntry:
+		mn.screen = &textScreen{title: controlsEntry, text: controlsText}
+	case highScoresEntry:
+		mn.screen = newHighScoresScreen(g)
+	case profilesEntry:
+		mn.screen = newProfilesScreen(g)
+	case quitEntry:
+		return mn.quit()
+	}
+	return nil
+}
+
+// Start a new game at the level
+func (mn *Menu) newGame(levelName string) tea.Cmd {
+	g := mn.game
+	g.saveReplay()
+	g.resetGame(g.newGameRng(), levelName)
+	mn.screen = g
+	return g.resume()
+}
+
+// Save the game and quit, the problems are printed on exit
+func (mn *Menu) quit() tea.Cmd {
+	if err := mn.game.saveSnapshot(); err != nil {
+		mn.game.warnings = append(mn.game.warnings, "The game could not be saved: "+err.Error())
+	}
+	mn.game.saveState()
+	return tea.Quit
+}
+
+// Warnings returns the problems with the saved files not shown in the menu yet
+func (mn *Menu) Warnings() []string {
+	return mn.game.warnings
+}
+
+// Show the problems with the saved files on a warning screen
+func (mn *Menu) showWarnings() {
+	if len(mn.game.warnings) == 0 {
+		return
+	}
+	mn.screen = &textScreen{title: "Warning", text: strings.Join(mn.game.warnings, "\n")}
+	mn.game.warnings = nil
+}
+
+// Value of an on/off setting
+func onOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+// Badge styles of ghosts found in the configuration
+func (m *Model) ghostBadgeStyles() []string {
+	styles := slices.Collect(maps.Keys(m.Badges.Ghosts))
+	for _, ghost := range m.Ghosts {
+		for style := range ghost.Badges {
+			if !slices.Contains(styles, style) {
+				styles = append(styles, style)
+			}
+		}
+	}
+	slices.Sort(styles)
+	return styles
+}
+
+var controlsText = strings.Join([]string{
+	"Arrow keys  Move Pac-Man",
+	"Space       Continue after a level or a lost life",
+	"P           Pause or resume, the game also pauses when the terminal loses focus",
+	"Ctrl+Z      Pause and suspend to the shell",
+	"H           Show high scores when the game is over",
+	"M           Mute or unmute the sound",
+	"N           Show or hide the minimap of mazes larger than the terminal",
+	"Q           Leave the game for the menu, Continue resumes it",
+	"Ctrl+C      Quit",
+}, "\n")
+
+func (mn *Menu) View() string {
+	if mn.screen != nil {
+		return mn.screen.View()
+	}
+	if mn.splash {
+		return ui.HUDStyle.Render("PacManTea") + "\n\nA terminal Pac-Man. Press any key."
+	}
+	view := ui.HUDStyle.Render("PacManTea") + "  Profile: " + state.Profile() + "\n"
+	for i, entry := range mn.entries() {
+		if i == mn.cursor {
+			view += "\n" + ui.HUDStyle.Render("> "+entry)
+			continue
+		}
+		view += "\n  " + entry
+	}
+	return view + "\n\nUse arrow keys to choose and Enter to select."
+}
@@ -1,369 +1,249 @@
 package model
 
 import (
+	"cmp"
 	"context"
 	"log"
-	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/engine"
+	"github.com/vinser/pacmantea/internal/replay"
 	"github.com/vinser/pacmantea/internal/sound"
 	"github.com/vinser/pacmantea/internal/state"
 	"github.com/vinser/pacmantea/internal/ui"
 	"github.com/vinser/pacmantea/internal/utils"
 )
 
-type Entity struct {
-	Position utils.Point
-	Move     utils.Direction
-	Style    lipgloss.Style
-	Name     string
-	Badge    rune
-}
-
-type Pacman struct {
-	Entity
-	ChewState     bool
-	RampantState  bool
-	CooldownState bool
-}
-
-type Ghost struct {
-	Entity
-	Dead         bool
-	RevivalPoint utils.Point
-}
-
-type Dot struct {
-	Entity
-}
-
-type Energizer struct {
-	Entity
-}
+// Lives at the start of a game if the difficulty has none
+const defaultStartingLives = 5
 
 type Model struct {
 	Ctx    context.Context
 	Cancel context.CancelFunc
 	config.Config
 	state.State
-	CurrentLevel int
-	CurrentSart  time.Time
-	Maze         []string
-	Pacman       Pacman
-	Dots         []Dot
-	Energizers   []Energizer
-	Ghosts       map[string]Ghost
-	LevelScore   int
-	GameScore    int
-	GameOver     bool
-	LevelWin     bool
-	GameWin      bool
-	Lives        int
-	Sounds       map[string]sound.Sound
+	CurrentLevel   int
+	Game           *engine.Game
+	ChewState      bool
+	GameScore      int
+	GameOver       bool
+	LevelWin       bool
+	Paused         bool // Game time is frozen until the player resumes
+	GameWin        bool
+	Lives          int // Lives left including the current one
+	ExtraLives     int // Extra lives awarded in the game
+	LifeFlash      int // Steps left to flash the lives in the HUD after an extra life
+	Sounds         map[string]sound.Sound
+	Seed           uint64         // Seed for every new game, zero means a random seed per game
+	Rng            *utils.Rand    // Random number generator of the current game
+	Steps          int            // Engine steps since the start of the game
+	Recording      *replay.Replay // Replay of the current game
+	ReplayPath     string         // File the replay of the last game was saved to
+	FruitHistory   []config.Fruit // Fruit eaten in the game
+	Breakdown      ScoreBreakdown // Points of the current level
+	GameDifficulty string         // Difficulty of the leaderboard the game goes to
+	MenuDifficulty string         // Difficulty chosen in the menu for all levels of the game, the level ones if empty
+	NameEntry      bool           // The player is entering a name for the leaderboard
+	PlayerName     string         // Name being entered
+	ShowScores     bool           // Show the leaderboard on the end of game screens
+	ScoreRank      int            // Index of the score of the last game in the leaderboard, -1 if it did not make it
+	input          engine.Input   // Pending player input, applied at the next game tick
+	generation     int            // Generation of the tick chains, ticks of older chains are dropped
+	ghostLooks     map[rune]cell  // Badges and styles of the ghosts of the current level by their maze markers
+	fruitLooks     []cell         // Symbols and styles of the fruit of the current level by kind
+	walls          [][]rune       // Pseudographics of the maze walls of the current level
+	wallFills      [][]rune       // Second columns of the walls in square cells
+	screen         cellBuffer     // Buffer the maze is drawn to, reused between frames
+	minimap        cellBuffer     // Buffer the minimap is drawn to
+	width, height  int            // Size of the terminal available to the game, zero until known
+	warnings       []string       // Problems with the saved files to show in the menu
+	monochrome     bool           // The terminal shows no colours, states are told apart by glyphs
 }
 
-func New() *Model {
-	state := state.Load()
+// New returns the model for a new game. A zero seed means a random one.
+func New(seed uint64) *Model {
 	config := config.Load()
-	// Initialize the game model with the loaded configuration and saved game
-	return InitialModel(config, state)
-}
-
-// InitialModel returns the initial model for the game
-func InitialModel(config config.Config, state state.State) *Model {
-	currntLevel := 0
-	for i, level := range config.Levels {
-		if level.Name == state.LevelName {
-			currntLevel = i
-			break
-		}
+	var warnings []string
+	if err := state.SetStore(config.SaveFormat); err != nil {
+		warnings = append(warnings, "The save format is not changed: "+err.Error())
 	}
-	if state.LevelName == "" {
-		state.LevelName = config.Levels[currntLevel].Name
+	state, loadErr := state.Load()
+	rng := utils.NewRand(seed)
+	if seed == 0 {
+		rng = utils.NewRand(utils.NewSeed())
 	}
-	maze := make([]string, len(config.Levels[currntLevel].Maze))
-	copy(maze, config.Levels[currntLevel].Maze)
-	// Ensure the maze has a minimum size of 5x5
-	if len(maze) < 5 || len(maze[0]) < 5 {
-		log.Fatal("The maze must be at least 5x5")
+	// Initialize the game model with the loaded configuration and saved game
+	m := InitialModel(config, state, rng)
+	m.Seed = seed
+	m.warnings = warnings
+	if loadErr != nil {
+		m.warnings = append(m.warnings, "The saved progress could not be loaded: "+loadErr.Error())
 	}
+	return m
+}
 
-	var pacmanEntity Pacman
-	dots := []Dot{}
-	energizers := []Energizer{}
-	ghosts := make(map[string]Ghost)
-
-	pacmanPlaced := false
-	ghostsPlaced := map[string]bool{"Blinky": false, "Inky": false, "Pinky": false, "Clyde": false}
+// InitialModel returns the initial model for the game.
+// The random number generator is shared by all levels of the game.
+func InitialModel(config config.Config, state state.State, rng *utils.Rand) *Model {
+	sounds, err := sound.LoadSamples()
 
-	for y, row := range maze {
-		for x, char := range row {
-			switch char {
-			case 'C':
-				pacmanEntity = initPacmanAt(utils.Point{X: x, Y: y})
-				pacmanPlaced = true
-				dots = append(dots, initDotAt(utils.Point{X: x, Y: y}))
-				maze[y] = utils.ReplaceAtIndex(maze[y], '.', x)
-			case '.':
-				dots = append(dots, initDotAt(utils.Point{X: x, Y: y}))
-			case 'o':
-				energizers = append(energizers, initEnergizerAt(x, y))
-			case 'B':
-				ghosts["Blinky"] = initGhostAt(utils.Point{X: x, Y: y}, ui.BlinkyStyle, "Blinky", 'B')
-				ghostsPlaced["Blinky"] = true
-				dots = append(dots, initDotAt(utils.Point{X: x, Y: y}))
-				maze[y] = utils.ReplaceAtIndex(maze[y], '.', x)
-			case 'I':
-				ghosts["Inky"] = initGhostAt(utils.Point{X: x, Y: y}, ui.InkyStyle, "Inky", 'I')
-				ghostsPlaced["Inky"] = true
-				dots = append(dots, initDotAt(utils.Point{X: x, Y: y}))
-				maze[y] = utils.ReplaceAtIndex(maze[y], '.', x)
-			case 'P':
-				ghosts["Pinky"] = initGhostAt(utils.Point{X: x, Y: y}, ui.PinkyStyle, "Pinky", 'P')
-				ghostsPlaced["Pinky"] = true
-				dots = append(dots, initDotAt(utils.Point{X: x, Y: y}))
-				maze[y] = utils.ReplaceAtIndex(maze[y], '.', x)
-			case 'Y':
-				ghosts["Clyde"] = initGhostAt(utils.Point{X: x, Y: y}, ui.ClydeStyle, "Clyde", 'Y')
-				ghostsPlaced["Clyde"] = true
-				dots = append(dots, initDotAt(utils.Point{X: x, Y: y}))
-				maze[y] = utils.ReplaceAtIndex(maze[y], '.', x)
-			}
-		}
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	// Randomly place the pacman from edges to center if not placed
-	if !pacmanPlaced {
-		pacmanEntity = placePacmanRandomly(maze)
+	m := &Model{
+		Config: config,
+		State:  state,
+		Sounds: sounds,
 	}
+	m.resetGame(rng, state.LevelName)
+	return m
+}
 
-	// Randomly place ghosts near the center if not placed
-	for name, placed := range ghostsPlaced {
-		if !placed {
-			var style lipgloss.Style
-			var badge rune
-			switch name {
-			case "Blinky":
-				style, badge = ui.BlinkyStyle, 'B'
-			case "Inky":
-				style, badge = ui.InkyStyle, 'I'
-			case "Pinky":
-				style, badge = ui.PinkyStyle, 'P'
-			case "Clyde":
-				style, badge = ui.ClydeStyle, 'Y'
-			}
-			ghosts[name] = placeGhostRandomly(maze, name, style, badge)
+// Reset the model to the start of a new game at the named level played with rng
+func (m *Model) resetGame(rng *utils.Rand, levelName string) {
+	m.Rng = rng
+	m.GameScore = 0
+	m.FruitHistory = nil
+	m.GameWin = false
+	m.Steps = 0
+	m.LevelName = levelName
+	m.MenuDifficulty = m.State.Difficulty // The menu choice applies to the whole game
+	m.loadLevel()
+	m.GameDifficulty = m.difficultyName()
+	m.NameEntry = false
+	m.ShowScores = false
+	m.Lives = m.difficulty().StartingLives
+	if m.Lives <= 0 {
+		m.Lives = defaultStartingLives
+	}
+	m.ExtraLives = 0
+	m.LifeFlash = 0
+	m.Recording = replay.New(rng.Seed, config.Hash(m.Config), m.LevelName)
+	m.Recording.GamesWon = m.GamesWon
+	m.Recording.Difficulty = m.MenuDifficulty
+}
+
+// Load the level named in the state, or the first level if there is no such level
+func (m *Model) loadLevel() {
+	m.CurrentLevel = 0
+	for i, level := range m.Levels {
+		if level.Name == m.LevelName {
+			m.CurrentLevel = i
+			break
 		}
 	}
-
-	// Convert maze walls to pseudographics for the current maze only
-	maze = replaceWallsWithPseudographics(maze)
-	sounds, err := sound.LoadSamples()
-
+	level := m.Levels[m.CurrentLevel]
+	m.LevelName = level.Name
+	ghosts, err := m.LevelGhosts(level)
 	if err != nil {
 		log.Fatal(err)
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	return &Model{
-		Ctx:          ctx,
-		Cancel:       cancel,
-		Config:       config,
-		State:        state,
-		CurrentLevel: currntLevel,
-		CurrentSart:  time.Now(),
-		Maze:         maze,
-		Pacman:       pacmanEntity,
-		Dots:         dots,
-		Energizers:   energizers,
-		Ghosts:       ghosts,
-		LevelScore:   0,
-		GameOver:     false,
-		LevelWin:     false,
-		Lives:        5, // Initialize with 5 lives
-		Sounds:       sounds,
+	game, err := engine.New(level, m.difficulty(), ghosts, m.Rng)
+	if err != nil {
+		log.Fatal(err)
 	}
+	if m.Cancel != nil {
+		m.Cancel() // Stop the ticks of the previous level
+	}
+	m.Ctx, m.Cancel = context.WithCancel(context.Background())
+	m.generation++
+	m.Game = game
+	m.loadLooks()
+	m.GameOver = false
+	m.LevelWin = false
+	m.Breakdown = ScoreBreakdown{}
+	m.input = engine.Input{}
 }
 
-func initPacmanAt(pos utils.Point) Pacman {
-	return Pacman{
-		Entity: Entity{
-			Position: pos,
-			Style:    ui.PacmanStyle,
-			Name:     "Pac-Man",
-			Badge:    'C',
-		},
-		ChewState: false,
+// Save the state, a failure is shown in the menu
+func (m *Model) saveState() {
+	if err := state.Save(m.State); err != nil {
+		m.warnings = append(m.warnings, "The progress could not be saved: "+err.Error())
 	}
 }
 
-func initDotAt(pos utils.Point) Dot {
-	return Dot{
-		Entity: Entity{
-			Position: pos,
-			Style:    ui.DotStyle,
-			Name:     "Dot",
-			Badge:    '.',
-		},
-	}
+// Set the looks of the walls, ghosts and fruit of the current level and the styles of the screen
+func (m *Model) loadLooks() {
+	theme := m.theme()
+	ui.ApplyTheme(theme)
+	m.monochrome = ui.Monochrome()
+	styles := []lipgloss.Style{
+		plainStyle:      lipgloss.NewStyle(),
+		wallStyle:       ui.WallStyle,
+		dotStyle:        ui.DotStyle,
+		energyStyle:     ui.EnergyStyle,
+		doorStyle:       ui.DoorStyle,
+		eyesStyle:       ui.EyesStyle,
+		frightenedStyle: ui.FrightenedStyle,
+		pacmanStyle:     ui.PacmanStyle,
+		rampantStyle:    ui.RampantStyle,
+		mapStyle:        ui.MapStyle,
+	}
+	level := m.Levels[m.CurrentLevel]
+	ghosts, _ := m.LevelGhosts(level) // The ghosts are checked when the level is loaded
+	m.ghostLooks = make(map[rune]cell, len(ghosts))
+	for _, ghost := range ghosts {
+		m.ghostLooks[[]rune(ghost.ID)[0]] = cell{text: m.GhostBadge(ghost, m.ghostBadgeStyle()), style: styleID(len(styles))}
+		styles = append(styles, ui.GhostStyle(cmp.Or(theme.Ghosts[ghost.ID], ghost.Color)))
+	}
+	m.fruitLooks = make([]cell, len(level.Fruit.Table))
+	for i, fruit := range level.Fruit.Table {
+		m.fruitLooks[i] = cell{text: fruit.Symbol, style: styleID(len(styles))}
+		styles = append(styles, ui.FruitStyle(fruit.Color))
+	}
+	m.screen.setStyles(styles)
+	m.minimap.codes = m.screen.codes
+	m.walls = wallGlyphs(m.Game.Grid)
+	m.wallFills = wallFills(m.Game.Grid, m.walls)
+	applyWallSet(m.walls, theme.Walls)
+	applyWallSet(m.wallFills, theme.Walls)
 }
 
-func initEnergizerAt(x, y int) Energizer {
-	return Energizer{
-		Entity: Entity{
-			Position: utils.Point{X: x, Y: y},
-			Style:    ui.EnergyStyle,
-			Name:     "Energizer",
-			Badge:    'o',
-		},
+// Theme of the current level, the one chosen in the menu wins over the level one
+func (m *Model) theme() config.Theme {
+	if theme, ok := m.Themes[m.State.Theme]; ok {
+		return theme
 	}
+	return m.Themes[m.Levels[m.CurrentLevel].Theme] // The default colours if the level has no theme
 }
 
-func initGhostAt(pos utils.Point, style lipgloss.Style, name string, badge rune) Ghost {
-	return Ghost{
-		Entity: Entity{
-			Position: pos,
-			Style:    style,
-			Name:     name,
-			Badge:    badge,
-		},
-		RevivalPoint: pos,
+// Name of the difficulty of the current level, the one chosen in the menu for the game
+// wins over the level one
+func (m *Model) difficultyName() string {
+	if _, ok := m.Difficulties[m.MenuDifficulty]; ok {
+		return m.MenuDifficulty
 	}
+	return m.Levels[m.CurrentLevel].DifficultyName
 }
 
-func placePacmanRandomly(maze []string) Pacman {
-	pos := utils.Point{}
-	free := utils.TraverseOrder(maze, utils.MazePerifery)
-	if len(free) > 0 {
-		pos = free[utils.Rng.Intn(min(4, len(free)))]
-	}
-	return initPacmanAt(pos)
-
+// Difficulty of the current level
+func (m *Model) difficulty() config.Difficulty {
+	return m.Difficulties[m.difficultyName()]
 }
 
-func placeGhostRandomly(maze []string, name string, style lipgloss.Style, badge rune) Ghost {
-	pos := utils.Point{}
-	free := utils.TraverseOrder(maze, utils.MazeCenter)
-	if len(free) > 0 {
-		pos = free[utils.Rng.Intn(min(6, len(free)))]
+// Badge style of Pac-Man in the current level, the one chosen in the menu wins over the level one
+func (m *Model) pacmanBadgeStyle() string {
+	if _, ok := m.Badges.Pacman[m.State.PacmanBadge]; ok {
+		return m.State.PacmanBadge
 	}
-	return initGhostAt(pos, style, name, badge)
-
+	return m.Levels[m.CurrentLevel].PacmanBadge
 }
 
-// Convert maze walls to pseudographics
-func replaceWallsWithPseudographics(maze []string) []string {
-	height := len(maze)
-	width := len(maze[0])
-
-	// Fix the outer walls tunnels
-	for i, line := range maze {
-		row := []rune(line)
-		switch {
-		case row[0] == ' ' && row[width-1] != ' ':
-			row[width-1] = ' '
-			maze[i] = string(row)
-		case row[0] != ' ' && row[width-1] == ' ':
-			row[0] = ' '
-			maze[i] = string(row)
-		}
+// Columns of a maze tile on the screen, the cells chosen in the menu win over the level ones,
+// which win over the theme ones
+func (m *Model) cellWidth() int {
+	cells := cmp.Or(m.State.Cells, m.Levels[m.CurrentLevel].Cells, m.theme().Cells)
+	if cells == config.SquareCells {
+		return 2
 	}
+	return 1
+}
 
-	// Create a new grid for pseudographics
-	newMaze := make([]string, height)
-	for y := 0; y < height; y++ {
-		newRow := []rune(maze[y])
-		for x := 0; x < width; x++ {
-			if maze[y][x] == '#' {
-				// Determine neighbors of the current cell
-				top := y > 0 && maze[y-1][x] == '#'
-				bottom := y < height-1 && maze[y+1][x] == '#'
-				left := x > 0 && maze[y][x-1] == '#'
-				right := x < width-1 && maze[y][x+1] == '#'
-
-				// Check if the wall is on the outer boundary
-				topBoundary := y == 0
-				bottomBoundary := y == height-1
-				leftBoundary := x == 0
-				rightBoundary := x == width-1
-
-				// Handle outer walls with double-line pseudographics
-				switch {
-				// Handle tunnels
-				case !topBoundary && !bottomBoundary && (leftBoundary || rightBoundary) && (left || right) && !bottom:
-					newRow[x] = '╨'
-				case !topBoundary && !bottomBoundary && (leftBoundary || rightBoundary) && (left || right) && !top:
-					newRow[x] = '╥'
-
-				case !topBoundary && !bottomBoundary && leftBoundary && !rightBoundary && !bottom:
-					newRow[x] = '╜'
-				case !topBoundary && !bottomBoundary && leftBoundary && !rightBoundary && !top:
-					newRow[x] = '╖'
-				case !topBoundary && !bottomBoundary && !leftBoundary && rightBoundary && !bottom:
-					newRow[x] = '╙'
-				case !topBoundary && !bottomBoundary && !leftBoundary && rightBoundary && !top:
-					newRow[x] = '╓'
-
-				case topBoundary && !bottomBoundary && leftBoundary && !rightBoundary:
-					newRow[x] = '╔' // Top-left corner
-				case topBoundary && !bottomBoundary && !leftBoundary && rightBoundary:
-					newRow[x] = '╗' // Top-right corner
-				case !topBoundary && bottomBoundary && leftBoundary && !rightBoundary:
-					newRow[x] = '╚' // Bottom-left corner
-				case !topBoundary && bottomBoundary && !leftBoundary && rightBoundary:
-					newRow[x] = '╝' // Bottom-right corner
-				case (topBoundary || bottomBoundary) && !leftBoundary && !rightBoundary && !top && !bottom:
-					newRow[x] = '═' // Horizontal boundary
-				case !topBoundary && !bottomBoundary && (leftBoundary || rightBoundary) && !left && !right && (top || bottom):
-					newRow[x] = '║' // Vertical boundary
-
-				// Handle connections between outer and inner walls
-				case !topBoundary && !bottomBoundary && leftBoundary && !rightBoundary && right:
-					newRow[x] = '╟' // Connects ║ with ─
-				case topBoundary && !bottomBoundary && !leftBoundary && !rightBoundary && bottom:
-					newRow[x] = '╤' // Connects ═ with │
-				case !topBoundary && !bottomBoundary && !leftBoundary && rightBoundary && left:
-					newRow[x] = '╢' // Connects ║ with ─
-				case !topBoundary && bottomBoundary && !leftBoundary && !rightBoundary && top:
-					newRow[x] = '╧' // Connects ═ with │
-
-				// Handle standalone walls
-				case !top && !bottom && !left && !right:
-					newRow[x] = '─'
-
-				// Handle inner walls
-				default:
-					switch {
-					case !left && !right && (top || bottom):
-						newRow[x] = '│'
-					case !top && !bottom && (left || right):
-						newRow[x] = '─'
-					case !top && bottom && !left && right:
-						newRow[x] = '┌'
-					case !top && bottom && left && !right:
-						newRow[x] = '┐'
-					case top && !bottom && left && !right:
-						newRow[x] = '┘'
-					case top && !bottom && !left && right:
-						newRow[x] = '└'
-					case top && bottom && !left && right:
-						newRow[x] = '├'
-					case !top && bottom && left && right:
-						newRow[x] = '┬'
-					case top && bottom && left && !right:
-						newRow[x] = '┤'
-					case top && !bottom && left && right:
-						newRow[x] = '┴'
-					case top && bottom && left && right:
-						newRow[x] = '┼'
-					default:
-						newRow[x] = '─'
-					}
-				}
-			}
-		}
-		newMaze[y] = string(newRow)
+// Badge style of ghosts in the current level, the one chosen in the menu wins over the level one
+func (m *Model) ghostBadgeStyle() string {
+	if m.State.GhostBadges != "" {
+		return m.State.GhostBadges
 	}
-
-	return newMaze
+	return m.Levels[m.CurrentLevel].GhostBadges
 }
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// The difficulty chosen in the menu during a game applies from the next game,
+// so that the replay and the leaderboard of the game stay right
+func TestDifficultyChangeInGame(t *testing.T) {
+	cfg := config.Load()
+	m := InitialModel(cfg, state.State{ElapsedTime: map[string]int{}}, utils.NewRand(1))
+	m.State.Difficulty = "Hard"
+	m.CurrentLevel++
+	m.LevelName = m.Levels[m.CurrentLevel].Name
+	m.loadLevel()
+	level := m.Levels[m.CurrentLevel]
+	if m.Game.Difficulty.GhostSpeed != cfg.Difficulties[level.DifficultyName].GhostSpeed || m.difficultyName() != level.DifficultyName {
+		t.Errorf("%s is played at %s instead of the level difficulty %s", level.Name, m.difficultyName(), level.DifficultyName)
+	}
+	if m.Recording.Difficulty != "" || m.GameDifficulty != cfg.Levels[0].DifficultyName {
+		t.Errorf("the game is recorded at %q for the %s leaderboard", m.Recording.Difficulty, m.GameDifficulty)
+	}
+	m.resetGame(utils.NewRand(2), "")
+	if m.difficultyName() != "Hard" || m.Recording.Difficulty != "Hard" || m.GameDifficulty != "Hard" {
+		t.Errorf("the next game is played at %s instead of Hard", m.difficultyName())
+	}
+}
+
+// A configuration without difficulties has empty leaderboards
+func TestHighScoresWithoutDifficulties(t *testing.T) {
+	cfg := config.Load()
+	cfg.Difficulties = nil
+	m := InitialModel(cfg, state.State{ElapsedTime: map[string]int{}}, utils.NewRand(1))
+	s := newHighScoresScreen(m)
+	for _, key := range []tea.KeyType{tea.KeyLeft, tea.KeyRight} {
+		s.Update(tea.KeyMsg{Type: key})
+	}
+	if view := s.View(); !strings.Contains(view, "No scores yet") {
+		t.Errorf("the high scores screen shows %q", view)
+	}
+}
+
+// A tick sent before the pause and delivered after the resume does not start a second tick chain
+func TestTickAfterResume(t *testing.T) {
+	cfg := config.Load()
+	m := InitialModel(cfg, state.State{ElapsedTime: map[string]int{}}, utils.NewRand(1))
+	tick, blink := gameTickMsg{generation: m.generation}, pacmanBlinkMsg{generation: m.generation}
+	m.pause()
+	m.resume()
+	steps, chew := m.Steps, m.ChewState
+	for _, msg := range []tea.Msg{tick, blink} {
+		if _, cmd := m.Update(msg); cmd != nil {
+			t.Errorf("%T of the stopped chain schedules the next one", msg)
+		}
+	}
+	if m.Steps != steps || m.ChewState != chew {
+		t.Error("ticks of the stopped chain advance the game")
+	}
+	if _, cmd := m.Update(gameTickMsg{generation: m.generation}); cmd == nil || m.Steps != steps+1 {
+		t.Error("ticks of the current chain do not advance the game")
+	}
+}
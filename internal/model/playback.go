@@ -0,0 +1,183 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/engine"
+	"github.com/vinser/pacmantea/internal/replay"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Playback speeds relative to the game speed
+var playbackSpeeds = []float64{0.25, 0.5, 1, 2, 4, 8}
+
+const (
+	playbackNormalSpeed = 2                          // Index of the normal speed in playbackSpeeds
+	playbackSeekSteps   = 10 * engine.TicksPerSecond // Steps to seek back or forth
+	playbackHoldSteps   = engine.TicksPerSecond      // Steps to hold the level end screens
+	playbackLines       = 3                          // Most lines of the playback status under the game
+)
+
+// Playback plays a recorded game back through the normal game view
+type Playback struct {
+	game       *Model
+	replay     *replay.Replay
+	configDiff bool // The replay was recorded with a different configuration
+	speed      int  // Index in playbackSpeeds
+	paused     bool
+	ended      bool
+	hold       int // Steps left to show a level end screen
+	generation int // Generation of the tick chain, ticks of older chains are dropped
+}
+
+// Message type for playback ticks
+type playbackTickMsg struct {
+	generation int
+}
+
+// NewPlayback returns the model playing the replay back
+func NewPlayback(r *replay.Replay) *Playback {
+	cfg := config.Load()
+	game := InitialModel(cfg, state.State{LevelName: r.LevelName, GamesWon: r.GamesWon, Difficulty: r.Difficulty, ElapsedTime: make(map[string]int)}, utils.NewRand(r.Seed))
+	game.Recording = nil // Do not record the playback itself
+	return &Playback{
+		game:       game,
+		replay:     r,
+		configDiff: config.Hash(cfg) != r.ConfigHash,
+		speed:      playbackNormalSpeed,
+	}
+}
+
+func (p *Playback) Init() tea.Cmd {
+	return p.tick()
+}
+
+// Command to trigger the next playback step
+func (p *Playback) tick() tea.Cmd {
+	if p.paused || p.ended {
+		return nil
+	}
+	generation := p.generation
+	d := time.Duration(float64(gameTickDuration) / playbackSpeeds[p.speed])
+	return tea.Tick(d, func(_ time.Time) tea.Msg {
+		return playbackTickMsg{generation: generation}
+	})
+}
+
+func (p *Playback) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.game.setSize(msg.Width, msg.Height-playbackLines) // Leave room for the playback status
+		return p, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return p, tea.Quit
+		case "m":
+			p.game.Mute = !p.game.Mute
+		case " ":
+			p.paused = !p.paused
+			p.generation++
+			return p, p.tick()
+		case ".":
+			// Step one tick while paused
+			if p.paused {
+				p.step()
+			}
+		case "+", "=":
+			p.speed = min(p.speed+1, len(playbackSpeeds)-1)
+		case "-":
+			p.speed = max(p.speed-1, 0)
+		case "left":
+			return p, p.seek(p.game.Steps - playbackSeekSteps)
+		case "right":
+			return p, p.seek(p.game.Steps + playbackSeekSteps)
+		case "home":
+			return p, p.seek(0)
+		}
+		return p, nil
+
+	case playbackTickMsg:
+		if msg.generation != p.generation {
+			return p, nil
+		}
+		if p.hold > 0 {
+			p.hold--
+		} else {
+			p.step()
+		}
+		return p, p.tick()
+	}
+	return p, nil
+}
+
+// Advance the playback by one engine step, continuing to the next level or life as the player did
+func (p *Playback) step() {
+	g := p.game
+	switch {
+	case p.ended:
+		return
+	case g.GameWin || (g.GameOver && g.Lives == 0) || g.Steps >= p.replay.Steps:
+		p.ended = true
+		return
+	case g.GameOver:
+		g.loadLevel()
+	case g.LevelWin:
+		if g.CurrentLevel >= len(g.Levels)-1 {
+			g.GameWin = true
+			p.ended = true
+			return
+		}
+		g.CurrentLevel++
+		g.LevelName = g.Levels[g.CurrentLevel].Name
+		g.loadLevel()
+	}
+	g.advance(engine.Input{Dir: p.replay.InputAt(g.Steps)})
+	g.ChewState = g.Steps/(engine.TicksPerSecond/2)%2 == 1
+	if g.GameOver || g.LevelWin {
+		p.hold = playbackHoldSteps
+	}
+}
+
+// Move the playback to the given step, replaying the game from the start if needed
+func (p *Playback) seek(target int) tea.Cmd {
+	g := p.game
+	target = max(0, min(target, p.replay.Steps))
+	mute := g.Mute
+	g.Mute = true
+	if target < g.Steps {
+		g.resetGame(utils.NewRand(p.replay.Seed), p.replay.LevelName)
+		g.Recording = nil
+		p.ended = false
+	}
+	for g.Steps < target && !p.ended {
+		p.step()
+	}
+	p.hold = 0
+	g.Mute = mute
+	// Restart the tick chain in case the playback had ended
+	p.generation++
+	return p.tick()
+}
+
+func (p *Playback) View() string {
+	view := p.game.View()
+	status := "Playing"
+	switch {
+	case p.ended:
+		status = "Ended"
+	case p.paused:
+		status = "Paused"
+	}
+	view += fmt.Sprintf("\nReplay %s: step %d/%d, speed x%g, seed %d", status, p.game.Steps, p.replay.Steps, playbackSpeeds[p.speed], p.replay.Seed)
+	if p.configDiff {
+		view += "\nWarning: the replay was recorded with a different configuration"
+	}
+	view += "\nSpace to pause, '.' to step, +/- for speed, left/right to seek, 'q' to quit"
+	return view
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/ui"
+)
+
+// Save the game of the current profile and load the game of the profile
+func (m *Model) switchProfile(name string) error {
+	if err := m.saveSnapshot(); err != nil {
+		m.warnings = append(m.warnings, "The game could not be saved: "+err.Error())
+	}
+	m.saveState()
+	if err := state.SetProfile(name); err != nil {
+		return err
+	}
+	s, err := state.Load()
+	if err != nil {
+		m.warnings = append(m.warnings, "The saved progress could not be loaded: "+err.Error())
+	}
+	m.State = s
+	m.resetGame(m.newGameRng(), s.LevelName)
+	if err := m.loadSnapshot(); err != nil {
+		m.warnings = append(m.warnings, "The saved game could not be continued: "+err.Error())
+	}
+	return nil
+}
+
+// Actions of the profiles screen that need a name
+const (
+	noProfileEntry = iota
+	newProfileEntry
+	renameProfileEntry
+)
+
+// Screen to switch, create, rename and delete profiles
+type profilesScreen struct {
+	game     *Model
+	profiles []string
+	cursor   int
+	entry    int    // Action the name is entered for
+	name     string // Name being entered
+	deleting bool   // Waiting for the confirmation to delete the chosen profile
+	message  string // Result of the last action
+}
+
+func newProfilesScreen(g *Model) *profilesScreen {
+	s := &profilesScreen{game: g}
+	s.load()
+	return s
+}
+
+// Read the profiles and put the cursor on the current one
+func (s *profilesScreen) load() {
+	profiles, err := state.Profiles()
+	if err != nil {
+		s.message = err.Error()
+		profiles = []string{state.Profile()}
+	}
+	s.profiles = profiles
+	s.cursor = max(0, slices.Index(profiles, state.Profile()))
+}
+
+func (s *profilesScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s *profilesScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+	if s.entry != noProfileEntry {
+		return s, s.updateEntry(key)
+	}
+	chosen := s.profiles[s.cursor]
+	if s.deleting {
+		s.deleting = false
+		s.message = ""
+		if key.String() == "y" {
+			if err := state.DeleteProfile(chosen); err != nil {
+				s.message = err.Error()
+			}
+			s.load()
+		}
+		return s, nil
+	}
+	s.message = ""
+	switch key.String() {
+	case "up":
+		s.cursor = (s.cursor + len(s.profiles) - 1) % len(s.profiles)
+	case "down":
+		s.cursor = (s.cursor + 1) % len(s.profiles)
+	case "enter", " ":
+		if chosen != state.Profile() {
+			if err := s.game.switchProfile(chosen); err != nil {
+				s.message = err.Error()
+				return s, nil
+			}
+		}
+		return s, backToMenu
+	case "n":
+		s.entry, s.name = newProfileEntry, ""
+	case "r":
+		s.entry, s.name = renameProfileEntry, chosen
+	case "d":
+		if chosen == state.Profile() {
+			s.message = "The current profile cannot be deleted."
+			return s, nil
+		}
+		s.deleting = true
+	case "q", "esc":
+		return s, backToMenu
+	}
+	return s, nil
+}
+
+// Edit the profile name and apply the action when it is entered
+func (s *profilesScreen) updateEntry(key tea.KeyMsg) tea.Cmd {
+	switch key.Type {
+	case tea.KeyEsc:
+		s.entry = noProfileEntry
+	case tea.KeyEnter:
+		var err error
+		switch s.entry {
+		case newProfileEntry:
+			if err = state.CreateProfile(s.name); err == nil {
+				err = s.game.switchProfile(s.name)
+			}
+		case renameProfileEntry:
+			err = state.RenameProfile(s.profiles[s.cursor], s.name)
+		}
+		if err != nil {
+			s.message = err.Error()
+			return nil
+		}
+		if s.entry == newProfileEntry {
+			return backToMenu
+		}
+		s.entry = noProfileEntry
+		s.load()
+	case tea.KeyBackspace:
+		if name := []rune(s.name); len(name) > 0 {
+			s.name = string(name[:len(name)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		if len([]rune(s.name))+len(key.Runes) <= state.MaxProfileNameLength {
+			s.name += string(key.Runes)
+		}
+	}
+	return nil
+}
+
+func (s *profilesScreen) View() string {
+	view := ui.HUDStyle.Render(profilesEntry) + "\n"
+	for i, name := range s.profiles {
+		if name == state.Profile() {
+			name += " (current)"
+		}
+		if i == s.cursor {
+			view += "\n" + ui.HUDStyle.Render("> "+name)
+			continue
+		}
+		view += "\n  " + name
+	}
+	view += "\n\n"
+	switch {
+	case s.entry == newProfileEntry:
+		view += fmt.Sprintf("Name of the new profile: %s_\nPress Enter to create, Esc to cancel.", s.name)
+	case s.entry == renameProfileEntry:
+		view += fmt.Sprintf("New name of %s: %s_\nPress Enter to rename, Esc to cancel.", s.profiles[s.cursor], s.name)
+	case s.deleting:
+		view += fmt.Sprintf("Delete %s with all its progress and high scores? Press 'y' to delete, any other key to keep it.", s.profiles[s.cursor])
+	default:
+		view += "Press Enter to switch, 'n' to create, 'r' to rename, 'd' to delete and Esc to go back."
+	}
+	if s.message != "" {
+		view += "\n\n" + s.message
+	}
+	return view
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"bytes"
+	"cmp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// Index of the style of a cell in the style table of the level
+type styleID int
+
+// Styles of the cells with fixed looks. The styles of the ghosts and the fruit of the level follow them.
+const (
+	plainStyle styleID = iota
+	wallStyle
+	dotStyle
+	energyStyle
+	doorStyle
+	eyesStyle
+	frightenedStyle
+	pacmanStyle
+	rampantStyle // Pac-Man in the rampant state
+	mapStyle     // Minimap outside the viewport
+)
+
+// Cell of the screen buffer
+type cell struct {
+	glyph rune
+	fill  rune   // Glyph of the second column of square cells, space if zero
+	text  string // Text shown instead of the glyph, like a badge
+	style styleID
+}
+
+// ANSI codes that turn a style on and off
+type styleCodes struct {
+	prefix, suffix string
+}
+
+// Screen buffer reused between frames. Consecutive cells of the same style
+// are rendered as a single run with one pair of ANSI codes.
+type cellBuffer struct {
+	width, height int
+	cellWidth     int // Columns of a cell on the screen, 2 for square cells
+	cells         []cell
+	codes         []styleCodes // By style id
+	out           bytes.Buffer
+}
+
+// Set the style table of the buffer
+func (b *cellBuffer) setStyles(styles []lipgloss.Style) {
+	const placeholder = "\uE000" // Private use rune that no style changes
+	b.codes = make([]styleCodes, len(styles))
+	for i, style := range styles {
+		prefix, suffix, _ := strings.Cut(style.Render(placeholder), placeholder)
+		b.codes[i] = styleCodes{prefix: prefix, suffix: suffix}
+	}
+}
+
+// Clear the buffer for a frame of the size
+func (b *cellBuffer) reset(width, height int) {
+	b.width, b.height = width, height
+	if cap(b.cells) < width*height {
+		b.cells = make([]cell, width*height)
+	}
+	b.cells = b.cells[:width*height]
+	clear(b.cells)
+}
+
+func (b *cellBuffer) set(x, y int, c cell) {
+	if x >= 0 && x < b.width && y >= 0 && y < b.height {
+		b.cells[y*b.width+x] = c
+	}
+}
+
+// Render the area of the buffer as rows of styled text
+func (b *cellBuffer) render(left, top, width, height int) string {
+	b.out.Reset()
+	for y := top; y < top+height; y++ {
+		if y > top {
+			b.out.WriteByte('\n')
+		}
+		row := b.cells[y*b.width+left : y*b.width+left+width]
+		overflow := 0 // Columns of the next cells taken by a text wider than its cell
+		for start := 0; start < len(row); {
+			style := row[start].style
+			end := start
+			for end < len(row) && row[end].style == style {
+				end++
+			}
+			codes := b.codes[style]
+			b.out.WriteString(codes.prefix)
+			for _, c := range row[start:end] {
+				overflow = b.writeCell(c, overflow)
+			}
+			b.out.WriteString(codes.suffix)
+			start = end
+		}
+	}
+	return b.out.String()
+}
+
+// Write the cell, or what is left of it after the overflow of the previous cells.
+// Returns the overflow of the cell into the next ones.
+func (b *cellBuffer) writeCell(c cell, overflow int) int {
+	cellWidth := max(1, b.cellWidth)
+	if overflow >= cellWidth {
+		return overflow - cellWidth
+	}
+	if overflow > 0 {
+		b.pad(cellWidth - overflow)
+		return 0
+	}
+	if c.text == "" {
+		b.out.WriteRune(c.glyph)
+		if cellWidth > 1 {
+			b.out.WriteRune(cmp.Or(c.fill, ' '))
+			b.pad(cellWidth - 2)
+		}
+		return 0
+	}
+	b.out.WriteString(c.text)
+	width := runewidth.StringWidth(c.text)
+	if width < cellWidth {
+		b.pad(cellWidth - width)
+		return 0
+	}
+	return width - cellWidth
+}
+
+func (b *cellBuffer) pad(columns int) {
+	for range columns {
+		b.out.WriteByte(' ')
+	}
+}
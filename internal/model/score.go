@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/vinser/pacmantea/internal/engine"
+)
+
+// Points of the current level by source, shown when the level is completed
+type ScoreBreakdown struct {
+	Dots      int
+	Fruit     int
+	Ghosts    int
+	Combo     int // Ghosts eaten on the current energizer
+	BestCombo int // Most ghosts eaten on one energizer
+	Seconds   int // Time the level took
+	ParTime   int // Seconds to beat for a time bonus
+	TimeBonus int
+}
+
+// Total points of the level
+func (b ScoreBreakdown) Total() int {
+	return b.Dots + b.Fruit + b.Ghosts + b.TimeBonus
+}
+
+// Add the points of the event to the breakdown
+func (b *ScoreBreakdown) add(e engine.Event) {
+	switch e.Type {
+	case engine.DotEaten:
+		b.Dots += e.Points
+	case engine.FruitEaten:
+		b.Fruit += e.Points
+	case engine.EnergizerEaten:
+		b.Combo = 0
+	case engine.GhostEaten:
+		b.Ghosts += e.Points
+		b.Combo++
+		b.BestCombo = max(b.BestCombo, b.Combo)
+	}
+}
+
+// Multiplier of the time bonus, it grows with every game won
+func (m *Model) bonusMultiplier() int {
+	return m.GamesWon + 1
+}
+
+// Finish the scoring of the completed level: the time bonus is the speed bonus of the difficulty
+// times the multiplier for every second under the par time
+func (m *Model) scoreLevel() {
+	b := &m.Breakdown
+	b.Seconds = m.Game.Tick / engine.TicksPerSecond
+	b.ParTime = m.Levels[m.CurrentLevel].ParTime
+	b.TimeBonus = m.Game.Difficulty.SpeedBonus * m.bonusMultiplier() * max(0, b.ParTime-b.Seconds)
+	m.GameScore += b.Total()
+	m.recordGameScore()
+}
+
+// Score breakdown of the completed level
+func (m *Model) breakdownView() string {
+	b := m.Breakdown
+	view := fmt.Sprintf("\nDots:       %6d", b.Dots)
+	view += fmt.Sprintf("\nFruit:      %6d", b.Fruit)
+	view += fmt.Sprintf("\nGhosts:     %6d (best combo %d)", b.Ghosts, b.BestCombo)
+	if b.ParTime > 0 {
+		view += fmt.Sprintf("\nTime bonus: %6d (%ds, par %ds, x%d)", b.TimeBonus, b.Seconds, b.ParTime, m.bonusMultiplier())
+	}
+	view += fmt.Sprintf("\nLevel total:%6d", b.Total())
+	view += fmt.Sprintf("\nGame score: %6d, high score %d", m.GameScore, m.HighScore)
+	return view
+}
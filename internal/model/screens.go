@@ -0,0 +1,200 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/ui"
+)
+
+// Screen to choose one of the items
+type listScreen struct {
+	title  string
+	items  []string
+	cursor int
+	choose func(i int) tea.Cmd // Called with the index of the chosen item
+}
+
+func (s *listScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s *listScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up":
+			s.cursor = (s.cursor + len(s.items) - 1) % len(s.items)
+		case "down":
+			s.cursor = (s.cursor + 1) % len(s.items)
+		case "enter", " ":
+			return s, s.choose(s.cursor)
+		case "q", "esc":
+			return s, backToMenu
+		}
+	}
+	return s, nil
+}
+
+func (s *listScreen) View() string {
+	view := ui.HUDStyle.Render(s.title) + "\n"
+	for i, item := range s.items {
+		if i == s.cursor {
+			view += "\n" + ui.HUDStyle.Render("> "+item)
+			continue
+		}
+		view += "\n  " + item
+	}
+	return view + "\n\nUse arrow keys to choose, Enter to select and Esc to go back."
+}
+
+// Setting with a list of values to cycle through
+type setting struct {
+	label  string
+	values []string
+	index  int
+	apply  func(value string) // Called with the new value, empty for levelDefault
+}
+
+// Setting with the current value chosen, an empty value is levelDefault
+func newSetting(label string, values []string, current string, apply func(value string)) setting {
+	if current == "" {
+		current = levelDefault
+	}
+	return setting{label: label, values: values, index: max(0, slices.Index(values, current)), apply: apply}
+}
+
+// Screen to change settings
+type settingsScreen struct {
+	title    string
+	settings []setting
+	cursor   int
+	footer   string
+}
+
+func (s *settingsScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s *settingsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+	st := &s.settings[s.cursor]
+	switch key.String() {
+	case "up":
+		s.cursor = (s.cursor + len(s.settings) - 1) % len(s.settings)
+	case "down":
+		s.cursor = (s.cursor + 1) % len(s.settings)
+	case "left":
+		st.index = (st.index + len(st.values) - 1) % len(st.values)
+		st.set()
+	case "right", "enter", " ":
+		st.index = (st.index + 1) % len(st.values)
+		st.set()
+	case "q", "esc":
+		return s, backToMenu
+	}
+	return s, nil
+}
+
+func (st *setting) set() {
+	value := st.values[st.index]
+	if value == levelDefault {
+		value = ""
+	}
+	st.apply(value)
+}
+
+func (s *settingsScreen) View() string {
+	view := ui.HUDStyle.Render(s.title) + "\n"
+	for i, st := range s.settings {
+		line := fmt.Sprintf("%s: < %s >", st.label, st.values[st.index])
+		if i == s.cursor {
+			view += "\n" + ui.HUDStyle.Render("> "+line)
+			continue
+		}
+		view += "\n  " + line
+	}
+	if s.footer != "" {
+		view += "\n\n" + s.footer
+	}
+	return view + "\n\nUse left/right arrows to change, Esc to go back."
+}
+
+// Screen with a text to read
+type textScreen struct {
+	title string
+	text  string
+}
+
+func (s *textScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s *textScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc", "enter", " ":
+			return s, backToMenu
+		}
+	}
+	return s, nil
+}
+
+func (s *textScreen) View() string {
+	return ui.HUDStyle.Render(s.title) + "\n\n" + s.text + "\n\nPress Esc to go back."
+}
+
+// Screen with the leaderboards of the level pack by difficulty
+type highScoresScreen struct {
+	configHash   string
+	difficulties []string
+	index        int
+	leaderboards map[string][]state.Score
+}
+
+func newHighScoresScreen(m *Model) *highScoresScreen {
+	difficulties := slices.Sorted(maps.Keys(m.Difficulties))
+	return &highScoresScreen{
+		configHash:   config.Hash(m.Config),
+		difficulties: difficulties,
+		index:        max(0, slices.Index(difficulties, m.difficultyName())),
+		leaderboards: m.Leaderboards,
+	}
+}
+
+func (s *highScoresScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s *highScoresScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "left":
+			if len(s.difficulties) > 0 {
+				s.index = (s.index + len(s.difficulties) - 1) % len(s.difficulties)
+			}
+		case "right":
+			if len(s.difficulties) > 0 {
+				s.index = (s.index + 1) % len(s.difficulties)
+			}
+		case "q", "esc", "enter", " ":
+			return s, backToMenu
+		}
+	}
+	return s, nil
+}
+
+func (s *highScoresScreen) View() string {
+	if len(s.difficulties) == 0 {
+		return "High scores\n\nNo scores yet\n\nPress Esc to go back." // The configuration has no difficulties
+	}
+	difficulty := s.difficulties[s.index]
+	view := leaderboardView(difficulty, s.leaderboards[state.LeaderboardKey(s.configHash, difficulty)], -1)
+	return view + "\n\nUse left/right arrows to change the difficulty, Esc to go back."
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/engine"
+	"github.com/vinser/pacmantea/internal/replay"
+	"github.com/vinser/pacmantea/internal/snapshot"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Game in progress saved on quit
+type gameSnapshot struct {
+	LevelName      string          `json:"level_name"`
+	Game           engine.Snapshot `json:"game"`
+	Seed           uint64          `json:"seed"`
+	Rng            []byte          `json:"rng"` // State of the random number generator
+	GameScore      int             `json:"game_score"`
+	Lives          int             `json:"lives"`
+	ExtraLives     int             `json:"extra_lives"`
+	Steps          int             `json:"steps"`
+	FruitHistory   []config.Fruit  `json:"fruit_history"`
+	Breakdown      ScoreBreakdown  `json:"breakdown"`
+	GameDifficulty string          `json:"game_difficulty"`
+	MenuDifficulty string          `json:"menu_difficulty"` // Difficulty chosen for all levels of the game, the level ones if empty
+	Recording      *replay.Replay  `json:"recording"`       // Replay of the game so far, it goes on after the restore
+}
+
+// Check if there is a game to continue
+func (m *Model) inProgress() bool {
+	return !m.finished() && (m.Steps > 0 || m.CurrentLevel > 0)
+}
+
+// Save the game in progress to be continued in the next run, or drop the saved one
+// and the replay of the game if there is nothing to continue
+func (m *Model) saveSnapshot() error {
+	if !m.inProgress() {
+		m.saveReplay()
+		return snapshot.Remove()
+	}
+	rng, err := m.Rng.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if m.Recording != nil {
+		m.Recording.Steps = m.Steps
+	}
+	return snapshot.Save(config.Hash(m.Config), gameSnapshot{
+		LevelName:      m.LevelName,
+		Game:           m.Game.Snapshot(),
+		Seed:           m.Rng.Seed,
+		Rng:            rng,
+		GameScore:      m.GameScore,
+		Lives:          m.Lives,
+		ExtraLives:     m.ExtraLives,
+		Steps:          m.Steps,
+		FruitHistory:   m.FruitHistory,
+		Breakdown:      m.Breakdown,
+		GameDifficulty: m.GameDifficulty,
+		MenuDifficulty: m.MenuDifficulty,
+		Recording:      m.Recording,
+	})
+}
+
+// Restore the game saved by the last run. Having no saved game is not an error.
+func (m *Model) loadSnapshot() error {
+	var s gameSnapshot
+	if err := snapshot.Load(config.Hash(m.Config), &s); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	current := -1
+	for i, level := range m.Levels {
+		if level.Name == s.LevelName {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		return fmt.Errorf("the saved game is at unknown level %q", s.LevelName)
+	}
+	ghosts, err := m.LevelGhosts(m.Levels[current])
+	if err != nil {
+		return err
+	}
+	rng := utils.NewRand(s.Seed)
+	game, err := engine.Restore(m.Levels[current], ghosts, rng, s.Game)
+	if err != nil {
+		return err
+	}
+	if err := rng.UnmarshalBinary(s.Rng); err != nil {
+		return err
+	}
+	if m.Cancel != nil {
+		m.Cancel()
+	}
+	m.Ctx, m.Cancel = context.WithCancel(context.Background())
+	m.generation++
+	m.CurrentLevel = current
+	m.LevelName = s.LevelName
+	m.Game = game
+	m.Rng = rng
+	m.GameScore = s.GameScore
+	m.Lives = s.Lives
+	m.ExtraLives = s.ExtraLives
+	m.Steps = s.Steps
+	m.FruitHistory = s.FruitHistory
+	m.Breakdown = s.Breakdown
+	m.GameDifficulty = s.GameDifficulty
+	m.MenuDifficulty = s.MenuDifficulty
+	m.Recording = s.Recording
+	m.GameOver = game.Lost
+	m.LevelWin = game.Won
+	m.GameWin = false
+	m.loadLooks()
+	return nil
+}
@@ -1,36 +1,34 @@
 package model
 
 import (
-	"time"
+	"context"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vinser/pacmantea/internal/engine"
+	"github.com/vinser/pacmantea/internal/replay"
 	"github.com/vinser/pacmantea/internal/sound"
-	"github.com/vinser/pacmantea/internal/state"
 	"github.com/vinser/pacmantea/internal/utils"
 )
 
-const (
-	ghostBonus = 40 // 40 points for first eated ghost in rampant state, 80 for second...
-)
-
-var ghostsEaten int = 0
-
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.setSize(size.Width, size.Height)
+		return m, nil
+	}
+	if m.NameEntry {
+		return m.updateNameEntry(msg)
+	}
 	if m.GameOver {
-		if m.Lives > 1 {
+		if m.Lives > 0 {
 			// Wait for spacebar to restart the current level
 			switch msg := msg.(type) {
 			case tea.KeyMsg:
 				switch msg.String() {
 				case " ":
-					lives := m.Lives - 1
-					m.Cancel()                                  // Deduct a life
-					newModel := InitialModel(m.Config, m.State) // Restart current level
-					newModel.Lives = lives                      // Preserve remaining lives
-					return newModel, newModel.Init()
-				case "q", "ctrl+c":
-					m.LevelName = m.Levels[m.CurrentLevel].Name
-					return m, tea.Quit
+					m.loadLevel()
+					return m, m.Init()
+				case "q":
+					return m, m.leave()
 				case "m":
 					m.Mute = !m.Mute
 				}
@@ -42,12 +40,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case " ":
-				m.Cancel()
-				m.LevelName = m.Levels[0].Name
-				newModel := InitialModel(m.Config, m.State)
-				return newModel, newModel.Init()
-			case "q", "ctrl+c":
-				return m, tea.Quit
+				m.saveState()
+				m.resetGame(m.newGameRng(), m.Levels[0].Name)
+				return m, m.Init()
+			case "q":
+				return m, m.leave()
+			case "h":
+				m.ShowScores = !m.ShowScores
 			case "m":
 				m.Mute = !m.Mute
 			}
@@ -55,19 +54,17 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 	if m.GameWin {
-		state.Save(m.State)
-		m.LevelName = ""
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case " ":
-				m.Cancel()
-				newModel := InitialModel(m.Config, m.State)
-				newModel.GameWin = false // Reset the winGame flag
-				// Start the timer for ghost movement and blinking
-				return newModel, newModel.Init()
-			case "q", "ctrl+c":
-				return m, tea.Quit
+				m.resetGame(m.newGameRng(), m.LevelName)
+				// Start the timer for game ticks and blinking
+				return m, m.Init()
+			case "q":
+				return m, m.leave()
+			case "h":
+				m.ShowScores = !m.ShowScores
 			case "m":
 				m.Mute = !m.Mute
 			}
@@ -79,6 +76,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.recordLevelElapsedTime()
 		if m.CurrentLevel >= len(m.Levels)-1 {
 			m.GameWin = true
+			m.GamesWon++
+			m.LevelName = "" // Start the next game from the first level
+			m.saveState()
+			m.saveReplay()
+			m.startNameEntry()
 			return m, nil
 		}
 		switch msg := msg.(type) {
@@ -90,20 +92,16 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.CurrentLevel++
 						m.LevelName = m.Levels[m.CurrentLevel].Name
 					}
-					lives := m.Lives
-					m.Cancel()
-					newModel := InitialModel(m.Config, m.State)
-					newModel.Lives = lives
-					// Start the timer for ghost movement and blinking
-					return newModel, newModel.Init()
-				}
-			case "q", "ctrl+c":
-				if m.CurrentLevel < len(m.Levels)-1 {
-					m.CurrentLevel++
-					m.LevelName = m.Levels[m.CurrentLevel].Name
+					m.loadLevel()
+					// Start the timer for game ticks and blinking
+					return m, m.Init()
 				}
-				state.Save(m.State)
-				return m, tea.Quit
+			case "q":
+				// Continue from the next level
+				m.CurrentLevel++
+				m.LevelName = m.Levels[m.CurrentLevel].Name
+				m.loadLevel()
+				return m, m.leave()
 			case "m":
 				m.Mute = !m.Mute
 			}
@@ -111,139 +109,223 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 	switch msg := msg.(type) {
+	case tea.BlurMsg:
+		// Pause when the terminal loses focus
+		m.pause()
+		return m, nil
+
 	case tea.KeyMsg:
 		sound.ClearSpeaker()
 		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
+		case "q":
+			return m, m.leave()
 		case "m":
 			m.Mute = !m.Mute
-		case "up":
-			if m.canMove(m.Pacman.Position.X, m.Pacman.Position.Y-1) {
-				m.Pacman.Position.Y--
-				m.Pacman.Move = utils.Direction{X: 0, Y: -1}
+		case "n":
+			m.Minimap = !m.Minimap
+		case "p":
+			if m.Paused {
+				return m, m.resume()
 			}
+			m.pause()
+		case "ctrl+z":
+			m.pause()
+			return m, tea.Suspend
+		}
+		if m.Paused {
+			return m, nil // Ignore moves until the game is resumed
+		}
+		switch msg.String() {
+		case "up":
+			m.input = engine.Input{Dir: utils.Direction{X: 0, Y: -1}}
 		case "down":
-			if m.canMove(m.Pacman.Position.X, m.Pacman.Position.Y+1) {
-				m.Pacman.Position.Y++
-				m.Pacman.Move = utils.Direction{X: 0, Y: 1}
-			}
+			m.input = engine.Input{Dir: utils.Direction{X: 0, Y: 1}}
 		case "left":
-			newX := m.tunnelMove(m.Pacman.Position.X - 1)
-			if m.canMove(newX, m.Pacman.Position.Y) {
-				m.Pacman.Position.X = newX
-				m.Pacman.Move = utils.Direction{X: -1, Y: 0}
-			}
+			m.input = engine.Input{Dir: utils.Direction{X: -1, Y: 0}}
 		case "right":
-			newX := m.tunnelMove(m.Pacman.Position.X + 1)
-			if m.canMove(newX, m.Pacman.Position.Y) {
-				m.Pacman.Position.X = newX
-				m.Pacman.Move = utils.Direction{X: 1, Y: 0}
-			}
+			m.input = engine.Input{Dir: utils.Direction{X: 1, Y: 0}}
 		}
+		return m, nil
 
-		// Check for dot collection
-		for i := len(m.Dots) - 1; i >= 0; i-- {
-			if m.Pacman.Position == m.Dots[i].Position {
-				m.LevelScore++
-				m.Maze[m.Pacman.Position.Y] = utils.ReplaceAtIndex(m.Maze[m.Pacman.Position.Y], ' ', m.Pacman.Position.X) // Replace dot with a space
-				m.Dots = append(m.Dots[:i], m.Dots[i+1:]...)
-				go m.PlaySound(sound.CHOMP)
-				break
-			}
+	case gameTickMsg:
+		if m.Paused || msg.generation != m.generation {
+			return m, nil // The tick was sent before the pause or by an older chain
 		}
-
-		// Check for win condition
-		if len(m.Dots) == 0 {
-			m.LevelWin = true
-			m.GameScore += m.LevelScore
-			go m.PlaySound(sound.INTERMISSION)
+		if m.tooSmall() {
+			m.pause() // No playing blind until the terminal is enlarged
 			return m, nil
 		}
-
-		// Check for energizer collection
-		for i := len(m.Energizers) - 1; i >= 0; i-- {
-			if m.Pacman.Position == m.Energizers[i].Position {
-				m.Maze[m.Pacman.Position.Y] = utils.ReplaceAtIndex(m.Maze[m.Pacman.Position.Y], ' ', m.Pacman.Position.X) // Replace dot with a space
-				m.Energizers = append(m.Energizers[:i], m.Energizers[i+1:]...)
-
-				// Activate rampant mode
-				m.Pacman.RampantState = true
-				ghostsEaten = 0
-				go m.PlaySound(sound.EATFRUIT)
-				return m, m.startRampantTimer()
-			}
-		}
-		cmd := m.checkGhostCollisions()
-		return m, cmd
-	case ghostMoveMsg:
-		// Move ghosts
-		for name, g := range m.Ghosts {
-			if g.Dead {
-				continue
-			}
-			if m.Pacman.RampantState {
-				g.Position = m.escapeMove(g.Position)
-			} else {
-				switch g.Name {
-				case "Blinky":
-					g.Position = m.straitMove(g.Position)
-				case "Inky":
-					g.Position = m.chaosMove(g.Position)
-				case "Pinky":
-					g.Position = m.predictMove(g.Position)
-				case "Clyde":
-					g.Position = m.cagyMove(g.Position)
-				}
-			}
-			m.Ghosts[name] = g
+		// Apply the pending input
+		m.advance(m.input)
+		m.input = engine.Input{}
+		if m.GameOver && m.Lives == 0 {
+			m.startNameEntry()
 		}
-		// Start the next tick for ghost movement
-		return m, tea.Batch(m.ghostMoveTick(), m.checkGhostCollisions())
+		// Start the next game tick
+		return m, m.gameTick()
 
 	case pacmanBlinkMsg:
+		if m.Paused || msg.generation != m.generation {
+			return m, nil
+		}
 		// Toggle the blink state
-		m.Pacman.ChewState = !m.Pacman.ChewState
+		m.ChewState = !m.ChewState
 		// Schedule the next blink
 		return m, m.pacmanBlinkTick()
+	}
 
-	case rampantEndMsg:
-		// Start cooldown
-		m.Pacman.CooldownState = true
-		return m, m.startCooldownTimer()
+	return m, nil
+}
 
-	case cooldownEndMsg:
-		// End cooldown and fully reset Pac-Man's state
-		m.Pacman.RampantState = false
-		m.Pacman.CooldownState = false
-		return m, nil
+// Pause the game and go back to the menu
+func (m *Model) leave() tea.Cmd {
+	m.Cancel() // Stop the ticks until the game is resumed
+	m.saveState()
+	return backToMenu
+}
 
-	case ghostReviveMsg:
-		// Revive the ghost at its revival point
-		ghost := m.Ghosts[msg.ghostName]
-		ghost.Dead = false
-		ghost.Position = ghost.RevivalPoint
-		m.Ghosts[msg.ghostName] = ghost
-		return m, nil
+// Stop the ticks so that no game time passes until the game is resumed
+func (m *Model) pause() {
+	if m.Paused || m.Game.Over() {
+		return
 	}
+	m.Paused = true
+	m.Cancel()
+}
 
-	return m, nil
+// Resume the game paused or left for the menu
+func (m *Model) resume() tea.Cmd {
+	m.Paused = false
+	m.Cancel()
+	m.Ctx, m.Cancel = context.WithCancel(context.Background())
+	m.generation++
+	return m.Init()
+}
+
+// Check if the game is over for good
+func (m *Model) finished() bool {
+	return m.GameWin || (m.GameOver && m.Lives == 0)
+}
+
+// Advance the game by one engine step, recording the input
+func (m *Model) advance(input engine.Input) {
+	if m.Recording != nil && input.Dir != (utils.Direction{}) {
+		m.Recording.Record(m.Steps, input.Dir)
+	}
+	m.Steps++
+	if m.LifeFlash > 0 {
+		m.LifeFlash--
+	}
+	m.handleEvents(m.Game.Step(input))
+	m.checkExtraLife()
+	if m.GameOver && m.Lives == 0 {
+		m.saveReplay()
+	}
+}
+
+// Award extra lives for the game score thresholds reached since the last check
+func (m *Model) checkExtraLife() {
+	extra := m.Game.Difficulty.ExtraLives
+	score := m.score()
+	reached := 0
+	for _, s := range extra.Scores {
+		if score >= s {
+			reached++
+		}
+	}
+	if extra.Every > 0 {
+		reached += score / extra.Every
+	}
+	if reached <= m.ExtraLives {
+		return
+	}
+	m.ExtraLives = reached
+	if maxLives := m.Game.Difficulty.MaxLives; maxLives > 0 && m.Lives >= maxLives {
+		return
+	}
+	m.Lives++
+	m.LifeFlash = lifeFlashSteps
+	m.playSound(sound.EXTRAPAC)
+}
+
+// Save the replay of the current game once it is over or abandoned
+func (m *Model) saveReplay() {
+	if m.Recording == nil || m.Steps == 0 {
+		return
+	}
+	m.Recording.Steps = m.Steps
+	path, err := replay.Save(m.Recording)
+	if err != nil {
+		m.warnings = append(m.warnings, "The replay could not be saved: "+err.Error())
+	} else {
+		m.ReplayPath = path
+	}
+	m.Recording = nil
+}
+
+// Map engine events to sounds and game state
+func (m *Model) handleEvents(events []engine.Event) {
+	for _, e := range events {
+		m.Breakdown.add(e)
+		switch e.Type {
+		case engine.DotEaten:
+			m.playSound(sound.CHOMP)
+		case engine.EnergizerEaten:
+			m.playSound(sound.CHOMP)
+		case engine.FruitEaten:
+			m.FruitHistory = append(m.FruitHistory, m.Game.Level.Fruit.Table[e.Kind])
+			m.playSound(sound.EATFRUIT)
+		case engine.GhostEaten:
+			m.playSound(sound.EATGHOST)
+		case engine.PacmanDied:
+			m.GameOver = true
+			m.Lives--
+			m.playSound(sound.DEATH)
+		case engine.LevelWon:
+			m.LevelWin = true
+			m.scoreLevel()
+			m.playSound(sound.INTERMISSION)
+		}
+	}
 }
 
 func (m *Model) recordLevelElapsedTime() {
-	elapsedTime := int(time.Since(m.CurrentSart).Seconds())
+	elapsedTime := m.Game.Tick / engine.TicksPerSecond // Game time, so pauses do not count
 	if m.State.ElapsedTime[m.LevelName] == 0 || elapsedTime < m.State.ElapsedTime[m.LevelName] {
 		m.State.ElapsedTime[m.LevelName] = elapsedTime
 	}
 }
 func (m *Model) recordGameScore() {
-	if m.State.HighScore < m.LevelScore {
-		m.State.HighScore = m.LevelScore
+	if m.State.HighScore < m.GameScore {
+		m.State.HighScore = m.GameScore
 	}
 }
 
+// Score of the game including the points of the level in progress
+func (m *Model) score() int {
+	if m.LevelWin {
+		return m.GameScore // The level points are already added
+	}
+	return m.GameScore + m.Game.Score
+}
+
+// Random number generator for a new game: the fixed seed if one was given, a fresh one otherwise
+func (m *Model) newGameRng() *utils.Rand {
+	if m.Seed != 0 {
+		return utils.NewRand(m.Seed)
+	}
+	return utils.NewRand(utils.NewSeed())
+}
+
 func (m *Model) PlaySound(name string) {
 	if s, ok := m.Sounds[name]; ok && !m.Mute {
 		sound.Play(s)
 	}
 }
+
+// Play the sound in the background
+func (m *Model) playSound(name string) {
+	if s, ok := m.Sounds[name]; ok && !m.Mute {
+		go sound.Play(s)
+	}
+}
@@ -2,137 +2,175 @@ package model
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/engine"
 	"github.com/vinser/pacmantea/internal/ui"
 	"github.com/vinser/pacmantea/internal/utils"
 )
 
+// Eaten ghosts returning to the ghost house
+const eyesChar = '"'
+
+// Frightened ghosts in terminals without colours
+const frightenedChar = 'w'
+
+// Rampant Pac-Man in terminals without colours
+const rampantChar = '@'
+
+// Number of the last eaten fruits shown in the HUD
+const fruitHistoryLength = 7
+
+// Steps the lives flash in the HUD after an extra life and steps of each blink
+const (
+	lifeFlashSteps = 3 * engine.TicksPerSecond
+	lifeFlashBlink = engine.TicksPerSecond / 4
+)
+
 // View function to render entities
 func (m *Model) View() string {
+	if m.NameEntry {
+		return m.nameEntryView()
+	}
+	if m.ShowScores && (m.GameWin || (m.GameOver && m.Lives == 0)) {
+		return m.highScoresView()
+	}
 	if m.LevelWin {
 		if m.GameWin {
-			return "You Win! \nPress space to restart. Press 'q' to quit."
+			return fmt.Sprintf("You Win! Seed: %d\nPress space to restart, 'h' for high scores. Press 'q' for the menu.\n", m.Rng.Seed) + m.breakdownView() + m.replayInfo()
 		} else {
-			view := fmt.Sprintf("Level %d completed! \nPress space to continue. Press 'q' to quit.", m.CurrentLevel+1)
-			view += fmt.Sprintf("\nLevel elapsed time: %d seconds!", m.ElapsedTime[m.LevelName])
-			view += fmt.Sprintf("\n%v", m.ElapsedTime)
+			view := fmt.Sprintf("Level %d completed! \nPress space to continue. Press 'q' for the menu.\n", m.CurrentLevel+1)
+			view += m.breakdownView()
+			view += fmt.Sprintf("\nBest time of the level: %d seconds", m.ElapsedTime[m.LevelName])
 			return view
 		}
 	}
 
 	if m.GameOver {
-		if m.Lives > 1 {
-			return fmt.Sprintf("You lost a life! Lives remaining: %d.\nPress space to restart the current level. Press 'q' to quit.", m.Lives-1)
+		if m.Lives > 0 {
+			return fmt.Sprintf("You lost a life! Lives remaining: %d.\nPress space to restart the current level. Press 'q' for the menu.", m.Lives)
 		}
-		return "Game Over! \nPress space to restart from the beginning. Press 'q' to quit."
+		return fmt.Sprintf("Game Over! Score: %d, seed: %d\nPress space to restart from the beginning, 'h' for high scores. Press 'q' for the menu.", m.GameScore, m.Rng.Seed) + m.replayInfo()
 	}
-	grid := make([]string, len(m.Maze))
-	copy(grid, m.Maze)
-
-	// Place dots
-	for _, d := range m.Dots {
-		grid[d.Position.Y] = utils.ReplaceAtIndex(grid[d.Position.Y], '·', d.Position.X)
+	if m.tooSmall() {
+		return m.tooSmallView()
 	}
-
-	// Place energizers
-	for _, e := range m.Energizers {
-		grid[e.Position.Y] = utils.ReplaceAtIndex(grid[e.Position.Y], 'o', e.Position.X)
+	view := m.mazeView()
+	lives := fmt.Sprintf("Lives: %d", m.Lives)
+	if m.LifeFlash > 0 && m.LifeFlash/lifeFlashBlink%2 == 0 {
+		lives = ui.FlashStyle.Render(lives) // Flash the lives after an extra life
 	}
-
-	// Place ghosts
-	for _, g := range m.Ghosts {
-		ghostChar := g.Badge
-		if g.Dead {
-			ghostChar = ' '
+	view += fmt.Sprintf("\nLevel: %d/%d, Score: %d, Dots: %d, ", m.CurrentLevel+1, len(m.Levels), m.score(), m.Game.DotsLeft) + lives
+	if len(m.FruitHistory) > 0 {
+		view += ", Fruit: "
+		for _, f := range m.FruitHistory[max(0, len(m.FruitHistory)-fruitHistoryLength):] {
+			view += renderFruit(f)
 		}
-		grid[g.Position.Y] = utils.ReplaceAtIndex(grid[g.Position.Y], ghostChar, g.Position.X)
+	}
+	if m.Paused {
+		view += "\n" + ui.FlashStyle.Render("Paused") + " Press 'p' to resume, 'q' for the menu, 'm' to mute"
+	} else {
+		view += "\nUse arrow keys to move. Press 'p' to pause, 'q' for the menu, 'm' to mute"
 	}
 
-	// Place the pacman with chewing effect
-	pacmanChar := 'C'
-	if m.Pacman.ChewState {
-		pacmanChar = 'c'
-	}
-	grid[m.Pacman.Position.Y] = utils.ReplaceAtIndex(grid[m.Pacman.Position.Y], pacmanChar, m.Pacman.Position.X)
-
-	// Apply styles to the grid
-	for y, row := range grid {
-		coloredRow := ""
-		for _, rn := range row {
-			switch rn {
-			case '│', '─', '┌', '┐', '└', '┘', '├', '┤', '┬', '┴', '┼', '║', '═', '╔', '╗', '╚', '╝', '╟', '╢', '╤', '╧', '╖', '╓', '╜', '╙', '╨', '╥':
-				coloredRow += ui.WallStyle.Render(string(rn))
-			case 'C', 'c':
-				coloredRow += renderPacman(m, rn)
-			case 'B', 'I', 'P', 'Y':
-				coloredRow += renderGhost(m, rn)
-			case '.':
-				coloredRow += ui.DotStyle.Render(string(rn))
-			case 'o':
-				coloredRow += ui.EnergyStyle.Render(string(rn))
+	return m.place(view)
+}
+
+// Where the replay of the finished game was saved
+func (m *Model) replayInfo() string {
+	if m.ReplayPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("\nReplay saved to %s", m.ReplayPath)
+}
+
+func renderFruit(f config.Fruit) string {
+	return ui.FruitStyle(f.Color).Render(f.Symbol)
+}
+
+// Draw the maze with the entities to the screen buffer
+func (m *Model) drawMaze() {
+	grid := m.Game.Grid
+	m.screen.reset(grid.Width, grid.Height)
+	m.screen.cellWidth = m.cellWidth()
+	for y := 0; y < grid.Height; y++ {
+		for x := 0; x < grid.Width; x++ {
+			switch grid.At(x, y) {
+			case engine.Wall:
+				m.screen.set(x, y, cell{glyph: m.walls[y][x], fill: m.wallFills[y][x], style: wallStyle})
+			case engine.Dot:
+				m.screen.set(x, y, cell{glyph: '·', style: dotStyle})
+			case engine.Energizer:
+				m.screen.set(x, y, cell{glyph: 'o', style: energyStyle})
+			case engine.Door:
+				door := cell{glyph: '-', style: doorStyle}
+				if grid.At(x+1, y) == engine.Door {
+					door.fill = '-' // Doors wider than a tile are solid
+				}
+				m.screen.set(x, y, door)
 			default:
-				coloredRow += string(rn)
+				m.screen.set(x, y, cell{glyph: ' '})
 			}
 		}
-		grid[y] = coloredRow
 	}
 
-	// Build the string for display
-	view := strings.Join(grid, "\n")
-	view += fmt.Sprintf("\nLevel: %d/%d, Score: %d/%d, Lives: %d", m.CurrentLevel+1, len(m.Levels), m.LevelScore, len(m.Dots), m.Lives)
-	view += "\nUse arrow keys to move. Press 'q' to quit, 'm' to mute"
+	// Place the fruit
+	if f := m.Game.Fruit; f != nil {
+		m.screen.set(f.Position.X, f.Position.Y, m.fruitLooks[f.Kind])
+	}
 
-	return view
+	// Place ghosts
+	for _, g := range m.Game.Ghosts {
+		m.screen.set(g.Position.X, g.Position.Y, m.ghostCell(g))
+	}
+
+	// Place the pacman with chewing effect
+	m.screen.set(m.Game.Pacman.Position.X, m.Game.Pacman.Position.Y, m.pacmanCell())
 }
 
-func renderPacman(m *Model, r rune) string {
-	var rn string
-	switch r {
-	case 'C':
-		rn = m.Config.Badges.Pacman[m.Config.Levels[m.CurrentLevel].PacmanBadge]["open"]
-		if m.Pacman.RampantState {
-			return ui.EnergyStyle.Render(rn)
-		} else {
-			return m.Pacman.Style.Render(rn)
-		}
-	case 'c':
-		switch m.Pacman.Move {
+func (m *Model) pacmanCell() cell {
+	badges := m.Config.Badges.Pacman[m.pacmanBadgeStyle()]
+	badge := badges["open"]
+	if m.ChewState {
+		switch m.Game.Pacman.Move {
 		case utils.Direction{X: 1, Y: 0}: // Moving right
-			rn = m.Config.Badges.Pacman[m.Config.Levels[m.CurrentLevel].PacmanBadge]["right"]
+			badge = badges["right"]
 		case utils.Direction{X: -1, Y: 0}: // Moving left
-			rn = m.Config.Badges.Pacman[m.Config.Levels[m.CurrentLevel].PacmanBadge]["left"]
+			badge = badges["left"]
 		case utils.Direction{X: 0, Y: -1}: // Moving up
-			rn = m.Config.Badges.Pacman[m.Config.Levels[m.CurrentLevel].PacmanBadge]["up"]
+			badge = badges["up"]
 		case utils.Direction{X: 0, Y: 1}: // Moving down
-			rn = m.Config.Badges.Pacman[m.Config.Levels[m.CurrentLevel].PacmanBadge]["down"]
-		default:
-			rn = m.Config.Badges.Pacman[m.Config.Levels[m.CurrentLevel].PacmanBadge]["open"]
+			badge = badges["down"]
 		}
-		if m.Pacman.RampantState {
-			if m.Pacman.CooldownState {
-				return ui.PacmanStyle.Render(rn)
-			} else {
-				return ui.EnergyStyle.Render(rn)
-			}
-		} else {
-			return ui.PacmanStyle.Render(rn)
+	}
+	if m.Game.Pacman.RampantState && !(m.ChewState && m.Game.Pacman.CooldownState) {
+		if m.monochrome {
+			return cell{glyph: rampantChar} // Without colours rampant Pac-Man changes the badge
 		}
+		return cell{text: badge, style: rampantStyle}
 	}
-	return ui.PacmanStyle.Render(string(rn))
+	return cell{text: badge, style: pacmanStyle}
 }
 
-func renderGhost(m *Model, r rune) string {
-	rn := m.Config.Badges.Ghosts[m.Config.Levels[m.CurrentLevel].GhostBadges][string(r)]
-	switch r {
-	case 'B':
-		return ui.BlinkyStyle.Render(rn)
-	case 'I':
-		return ui.InkyStyle.Render(rn)
-	case 'P':
-		return ui.PinkyStyle.Render(rn)
-	case 'Y':
-		return ui.ClydeStyle.Render(rn)
-	}
-	return rn
+func (m *Model) ghostCell(g engine.Ghost) cell {
+	if g.State == engine.Eyes {
+		return cell{glyph: eyesChar, style: eyesStyle}
+	}
+	look, ok := m.ghostLooks[g.Badge]
+	if !ok {
+		look = cell{glyph: g.Badge}
+	}
+	if g.Frightened {
+		// Frightened ghosts blink when the rampant state is cooling down
+		blink := m.Game.Pacman.CooldownState && m.ChewState
+		switch {
+		case m.monochrome && !blink:
+			look = cell{glyph: frightenedChar} // Without colours frightened ghosts change their badge
+		case blink:
+			look.style = dotStyle
+		default:
+			look.style = frightenedStyle
+		}
+	}
+	return look
 }
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/engine"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Render a game in progress on every level of the default configuration
+func BenchmarkView(b *testing.B) {
+	lipgloss.SetColorProfile(termenv.TrueColor) // Render the styles even without a terminal
+	cfg := config.Load()
+	for _, level := range cfg.Levels {
+		b.Run(level.Name, func(b *testing.B) {
+			m := InitialModel(cfg, state.State{LevelName: level.Name, ElapsedTime: map[string]int{}}, utils.NewRand(1))
+			for i := 0; i < 100 && !m.Game.Over(); i++ {
+				m.advance(engine.Input{})
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				m.View()
+			}
+		})
+	}
+}
+
+// Styled character of the terminal output
+type styledRune struct {
+	r   rune
+	sgr string // Text attributes the character is shown with
+}
+
+// Decode the terminal output into characters with their text attributes, so that
+// outputs styling the same characters the same way are equal however they are grouped
+func decodeStyles(s string) []styledRune {
+	var out []styledRune
+	sgr := ""
+	for len(s) > 0 {
+		if rest, ok := strings.CutPrefix(s, "\x1b["); ok {
+			params, after, _ := strings.Cut(rest, "m")
+			if params == "" || params == "0" {
+				sgr = ""
+			} else {
+				sgr += ";" + params
+			}
+			s = after
+			continue
+		}
+		var r rune
+		for _, r = range s {
+			break
+		}
+		out = append(out, styledRune{r, sgr})
+		s = s[len(string(r)):]
+	}
+	return out
+}
+
+// Put the game in a fixed state with every kind of maze cell: eaten dots, rampant
+// Pac-Man, frightened and blinking ghosts, eyes and fruit in the maze and in the HUD
+func setViewState(m *Model) {
+	g := m.Game
+	var open []utils.Point // Cells with dots in reading order
+	for y := range g.Grid.Height {
+		for x := range g.Grid.Width {
+			if g.Grid.At(x, y) == engine.Dot {
+				open = append(open, utils.Point{X: x, Y: y})
+			}
+		}
+	}
+	for _, p := range open[:len(open)/3] {
+		g.Grid.Set(p.X, p.Y, engine.Empty)
+	}
+	g.Pacman.Position = open[len(open)/3-1]
+	g.Pacman.Move = utils.Direction{X: 1, Y: 0}
+	g.Pacman.RampantState = true
+	m.ChewState = true
+	for i := range g.Ghosts {
+		ghost := &g.Ghosts[i]
+		ghost.Position = open[len(open)/3+5*(i+1)]
+		ghost.State = engine.Active
+		switch i % 3 {
+		case 0:
+			ghost.Frightened = true
+		case 1:
+			ghost.State = engine.Eyes
+		}
+	}
+	if table := g.Level.Fruit.Table; len(table) > 0 {
+		g.Fruit = &engine.Fruit{Entity: engine.Entity{Position: open[len(open)-1]}, Kind: len(table) - 1}
+		m.FruitHistory = table
+	}
+	m.GameScore = 1234
+	m.Lives = 3
+}
+
+// The view of a fixed game state is styled as the view rendered cell by cell before
+// the cell buffer, which is kept in testdata
+func TestViewOutput(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	cfg := config.Load()
+	for i, level := range cfg.Levels {
+		want, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("view-level-%d.golden", i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := InitialModel(cfg, state.State{LevelName: level.Name, ElapsedTime: map[string]int{}}, utils.NewRand(1))
+		setViewState(m)
+		got, expected := decodeStyles(m.View()), decodeStyles(string(want))
+		for j := range min(len(got), len(expected)) {
+			if got[j] != expected[j] {
+				t.Fatalf("%s: character %d is %q styled %q, want %q styled %q", level.Name, j, got[j].r, got[j].sgr, expected[j].r, expected[j].sgr)
+			}
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("%s: the view has %d characters, want %d", level.Name, len(got), len(expected))
+		}
+	}
+}
+
+// Without colours the states of Pac-Man and ghosts are told apart by glyphs
+func TestViewMonochrome(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+	lipgloss.SetColorProfile(termenv.Ascii)
+	cfg := config.Load()
+	m := InitialModel(cfg, state.State{ElapsedTime: map[string]int{}}, utils.NewRand(1))
+	setViewState(m)
+	m.loadLooks()
+	view := m.View()
+	if !strings.ContainsRune(view, rampantChar) || !strings.ContainsRune(view, frightenedChar) {
+		t.Errorf("rampant Pac-Man or frightened ghosts are not told apart:\n%s", view)
+	}
+	m.Game.Pacman.RampantState = false
+	if strings.ContainsRune(m.View(), rampantChar) {
+		t.Error("Pac-Man is drawn rampant after the rampant state")
+	}
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/vinser/pacmantea/internal/engine"
+)
+
+// Lines of the HUD under the maze
+const hudHeight = 2
+
+// Smallest part of the maze worth playing in, smaller terminals get the "too small" screen
+const (
+	minViewWidth  = 21
+	minViewHeight = 9
+)
+
+// Maze cells shown by one minimap character, which is a braille pattern of 2x4 dots
+const (
+	minimapCellWidth  = 2
+	minimapCellHeight = 4
+)
+
+// Braille dot bits by column and row of the cell within the character
+var brailleDots = [minimapCellWidth][minimapCellHeight]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// Set the size of the terminal available to the game
+func (m *Model) setSize(width, height int) {
+	m.width, m.height = width, height
+}
+
+// Check if the terminal is too small to play in
+func (m *Model) tooSmall() bool {
+	if m.width == 0 || m.height == 0 {
+		return false // The size is not known yet
+	}
+	grid := m.Game.Grid
+	return m.width < min(grid.Width, minViewWidth)*m.cellWidth() || m.height-hudHeight < min(grid.Height, minViewHeight)
+}
+
+func (m *Model) tooSmallView() string {
+	grid := m.Game.Grid
+	return fmt.Sprintf("The terminal is too small: %dx%d.\nThe game needs at least %dx%d, please enlarge the window.\nPress 'q' for the menu.",
+		m.width, m.height, min(grid.Width, minViewWidth)*m.cellWidth(), min(grid.Height, minViewHeight)+hudHeight)
+}
+
+// Centre the view in the terminal. Lines are centred one by one, so that
+// a HUD wider than the terminal does not push the maze off the centre.
+func (m *Model) place(view string) string {
+	if m.width == 0 || m.height == 0 {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		lines[i] = lipgloss.PlaceHorizontal(m.width, lipgloss.Center, line)
+	}
+	return lipgloss.PlaceVertical(m.height, lipgloss.Center, strings.Join(lines, "\n"))
+}
+
+// Render the maze. Mazes larger than the terminal are shown through a viewport
+// that follows Pac-Man, with the minimap of the whole maze under it if it is on.
+func (m *Model) mazeView() string {
+	m.drawMaze()
+	grid := m.Game.Grid
+	if m.width == 0 || m.height == 0 {
+		return m.screen.render(0, 0, grid.Width, grid.Height)
+	}
+	width, height := m.width/m.cellWidth(), m.height-hudHeight // In tiles
+	if grid.Width <= width && grid.Height <= height {
+		return m.screen.render(0, 0, grid.Width, grid.Height)
+	}
+	mapWidth, mapHeight := m.minimapSize()
+	showMap := m.Minimap && mapWidth <= m.width && height-mapHeight >= minViewHeight
+	if showMap {
+		height -= mapHeight
+	}
+	width, height = min(width, grid.Width), min(height, grid.Height)
+	pacman := m.Game.Pacman.Position
+	left := max(0, min(pacman.X-width/2, grid.Width-width))
+	top := max(0, min(pacman.Y-height/2, grid.Height-height))
+	view := m.screen.render(left, top, width, height)
+	if showMap {
+		view += "\n" + m.minimapView(left, top, width, height)
+	}
+	return view
+}
+
+// Size of the minimap in characters
+func (m *Model) minimapSize() (width, height int) {
+	grid := m.Game.Grid
+	return (grid.Width + minimapCellWidth - 1) / minimapCellWidth, (grid.Height + minimapCellHeight - 1) / minimapCellHeight
+}
+
+// Render the walls of the whole maze and Pac-Man in braille patterns.
+// The part of the maze outside the viewport is dimmed.
+func (m *Model) minimapView(left, top, width, height int) string {
+	grid := m.Game.Grid
+	mapWidth, mapHeight := m.minimapSize()
+	m.minimap.reset(mapWidth, mapHeight)
+	pacman := m.Game.Pacman.Position
+	for my := 0; my < mapHeight; my++ {
+		for mx := 0; mx < mapWidth; mx++ {
+			c := cell{glyph: 0x2800, style: mapStyle} // Empty braille pattern
+			for dx := 0; dx < minimapCellWidth; dx++ {
+				for dy := 0; dy < minimapCellHeight; dy++ {
+					x, y := mx*minimapCellWidth+dx, my*minimapCellHeight+dy
+					if x >= left && x < left+width && y >= top && y < top+height && c.style == mapStyle {
+						c.style = wallStyle
+					}
+					if grid.At(x, y) == engine.Wall && grid.Inside(x, y) {
+						c.glyph |= brailleDots[dx][dy]
+					}
+					if x == pacman.X && y == pacman.Y {
+						c.glyph |= brailleDots[dx][dy]
+						c.style = pacmanStyle
+					}
+				}
+			}
+			m.minimap.set(mx, my, c)
+		}
+	}
+	return m.minimap.render(0, 0, mapWidth, mapHeight)
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/vinser/pacmantea/internal/config"
+	"github.com/vinser/pacmantea/internal/state"
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// The minimap is shown only when it fits the terminal
+func TestMinimapFits(t *testing.T) {
+	cfg := config.Load()
+	row := "#" + strings.Repeat(".", 58) + "#"
+	maze := []string{strings.Repeat("#", 60)}
+	for range 30 {
+		maze = append(maze, row)
+	}
+	maze = append(maze, strings.Repeat("#", 60))
+	maze[15] = "#" + strings.Repeat(".", 29) + "C" + strings.Repeat(".", 28) + "#"
+	cfg.Levels = []config.Level{{Name: "Wide", DifficultyName: cfg.Levels[0].DifficultyName, Maze: maze, Cells: config.NarrowCells}}
+	m := InitialModel(cfg, state.State{Minimap: true, ElapsedTime: map[string]int{}}, utils.NewRand(1))
+	for _, size := range []struct {
+		width, height int
+		minimap       bool
+	}{{25, 30, false}, {40, 30, true}, {40, 16, false}} {
+		m.setSize(size.width, size.height)
+		view := m.View()
+		braille := func(r rune) bool { return r >= 0x2800 && r <= 0x28FF }
+		if got := strings.ContainsFunc(view, braille); got != size.minimap {
+			t.Errorf("%dx%d: minimap shown %v, want %v", size.width, size.height, got, size.minimap)
+		}
+		for _, line := range strings.Split(m.mazeView(), "\n") {
+			if lipgloss.Width(line) > size.width {
+				t.Errorf("%dx%d: the maze line %q is wider than the terminal", size.width, size.height, line)
+			}
+		}
+	}
+}
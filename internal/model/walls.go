@@ -0,0 +1,161 @@
+package model
+
+import "github.com/vinser/pacmantea/internal/engine"
+
+// Pseudographics of the maze walls, cells other than walls are zero
+func wallGlyphs(grid engine.Grid) [][]rune {
+	height := grid.Height
+	width := grid.Width
+
+	// Create a new grid for pseudographics
+	glyphs := make([][]rune, height)
+	for y := 0; y < height; y++ {
+		newRow := make([]rune, width)
+		for x := 0; x < width; x++ {
+			if grid.At(x, y) == engine.Wall {
+				// Determine neighbors of the current cell, cells out of the maze are walls to the grid
+				top := y > 0 && grid.At(x, y-1) == engine.Wall
+				bottom := y < height-1 && grid.At(x, y+1) == engine.Wall
+				left := x > 0 && grid.At(x-1, y) == engine.Wall
+				right := x < width-1 && grid.At(x+1, y) == engine.Wall
+
+				// Check if the wall is on the outer boundary
+				topBoundary := y == 0
+				bottomBoundary := y == height-1
+				leftBoundary := x == 0
+				rightBoundary := x == width-1
+
+				// Handle outer walls with double-line pseudographics
+				switch {
+				// Handle tunnels
+				case !topBoundary && !bottomBoundary && (leftBoundary || rightBoundary) && (left || right) && !bottom:
+					newRow[x] = '╨'
+				case !topBoundary && !bottomBoundary && (leftBoundary || rightBoundary) && (left || right) && !top:
+					newRow[x] = '╥'
+
+				case !topBoundary && !bottomBoundary && leftBoundary && !rightBoundary && !bottom:
+					newRow[x] = '╜'
+				case !topBoundary && !bottomBoundary && leftBoundary && !rightBoundary && !top:
+					newRow[x] = '╖'
+				case !topBoundary && !bottomBoundary && !leftBoundary && rightBoundary && !bottom:
+					newRow[x] = '╙'
+				case !topBoundary && !bottomBoundary && !leftBoundary && rightBoundary && !top:
+					newRow[x] = '╓'
+
+				case topBoundary && !bottomBoundary && leftBoundary && !rightBoundary:
+					newRow[x] = '╔' // Top-left corner
+				case topBoundary && !bottomBoundary && !leftBoundary && rightBoundary:
+					newRow[x] = '╗' // Top-right corner
+				case !topBoundary && bottomBoundary && leftBoundary && !rightBoundary:
+					newRow[x] = '╚' // Bottom-left corner
+				case !topBoundary && bottomBoundary && !leftBoundary && rightBoundary:
+					newRow[x] = '╝' // Bottom-right corner
+				case (topBoundary || bottomBoundary) && !leftBoundary && !rightBoundary && !top && !bottom:
+					newRow[x] = '═' // Horizontal boundary
+				case !topBoundary && !bottomBoundary && (leftBoundary || rightBoundary) && !left && !right && (top || bottom):
+					newRow[x] = '║' // Vertical boundary
+
+				// Handle connections between outer and inner walls
+				case !topBoundary && !bottomBoundary && leftBoundary && !rightBoundary && right:
+					newRow[x] = '╟' // Connects ║ with ─
+				case topBoundary && !bottomBoundary && !leftBoundary && !rightBoundary && bottom:
+					newRow[x] = '╤' // Connects ═ with │
+				case !topBoundary && !bottomBoundary && !leftBoundary && rightBoundary && left:
+					newRow[x] = '╢' // Connects ║ with ─
+				case !topBoundary && bottomBoundary && !leftBoundary && !rightBoundary && top:
+					newRow[x] = '╧' // Connects ═ with │
+
+				// Handle standalone walls
+				case !top && !bottom && !left && !right:
+					newRow[x] = '─'
+
+				// Handle inner walls
+				default:
+					switch {
+					case !left && !right && (top || bottom):
+						newRow[x] = '│'
+					case !top && !bottom && (left || right):
+						newRow[x] = '─'
+					case !top && bottom && !left && right:
+						newRow[x] = '┌'
+					case !top && bottom && left && !right:
+						newRow[x] = '┐'
+					case top && !bottom && left && !right:
+						newRow[x] = '┘'
+					case top && !bottom && !left && right:
+						newRow[x] = '└'
+					case top && bottom && !left && right:
+						newRow[x] = '├'
+					case !top && bottom && left && right:
+						newRow[x] = '┬'
+					case top && bottom && left && !right:
+						newRow[x] = '┤'
+					case top && !bottom && left && right:
+						newRow[x] = '┴'
+					case top && bottom && left && right:
+						newRow[x] = '┼'
+					default:
+						newRow[x] = '─'
+					}
+				}
+			}
+		}
+		glyphs[y] = newRow
+	}
+
+	return glyphs
+}
+
+// Glyphs of the second column of the walls in square cells: the wall line
+// continues to the right when the next cell is a wall, other cells are zero
+func wallFills(grid engine.Grid, glyphs [][]rune) [][]rune {
+	fills := make([][]rune, grid.Height)
+	for y := range fills {
+		fills[y] = make([]rune, grid.Width)
+		for x := 0; x < grid.Width-1; x++ {
+			if grid.At(x, y) != engine.Wall || grid.At(x+1, y) != engine.Wall {
+				continue
+			}
+			switch glyphs[y][x] {
+			case '─', '┌', '└', '├', '┬', '┴', '┼', '╟', '╓', '╙', '╨', '╥':
+				fills[y][x] = '─'
+			case '═', '╔', '╚', '╤', '╧':
+				fills[y][x] = '═'
+			}
+		}
+	}
+	return fills
+}
+
+// Arms of the classic wall glyphs: up 1, down 2, left 4, right 8
+var wallArms = map[rune]int{
+	'─': 12, '│': 3, '┌': 10, '┐': 6, '└': 9, '┘': 5, '├': 11, '┤': 7, '┬': 14, '┴': 13, '┼': 15,
+	'═': 12, '║': 3, '╔': 10, '╗': 6, '╚': 9, '╝': 5, '╟': 11, '╢': 7, '╤': 14, '╧': 13,
+	'╖': 6, '╓': 10, '╜': 5, '╙': 9, '╨': 13, '╥': 14,
+}
+
+// Wall glyph sets of themes indexed by the arms of the wall. The classic set
+// has double outer walls and single inner ones and needs no mapping.
+var wallSets = map[string][]rune{
+	"single":  []rune("─││││┘┐┤─└┌├─┴┬┼"),
+	"rounded": []rune("─││││╯╮┤─╰╭├─┴┬┼"),
+	"heavy":   []rune("━┃┃┃━┛┓┫━┗┏┣━┻┳╋"),
+	"double":  []rune("═║║║═╝╗╣═╚╔╠═╩╦╬"),
+	"ascii":   []rune("-|||-+++-+++-+++"),
+	"blocks":  []rune("████████████████"),
+}
+
+// Replace the classic wall glyphs with the ones of the named set
+func applyWallSet(glyphs [][]rune, name string) {
+	set, ok := wallSets[name]
+	if !ok {
+		return
+	}
+	for _, row := range glyphs {
+		for x, r := range row {
+			if arms, ok := wallArms[r]; ok {
+				row[x] = set[arms]
+			}
+		}
+	}
+}
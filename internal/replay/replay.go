@@ -0,0 +1,163 @@
+// Package replay records the inputs of a game and stores them in compact
+// replay files. A game is fully determined by its seed, configuration and
+// the inputs applied at each engine step, so a replay is enough to play it back.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/vinser/pacmantea/internal/utils"
+)
+
+// Version of the replay file format
+const Version = 2
+
+// Input is a direction applied at an engine step of the game
+type Input struct {
+	Step int
+	Dir  utils.Direction
+}
+
+// Inputs are stored as a string of step deltas followed by direction letters, e.g. "12U5L"
+type Inputs []Input
+
+type Replay struct {
+	Version    int       `json:"version"`
+	Seed       uint64    `json:"seed"`
+	ConfigHash string    `json:"config_hash"`
+	LevelName  string    `json:"level_name"` // Level the game started at
+	GamesWon   int       `json:"games_won"`  // Games won by the player before, they multiply the time bonus
+	Difficulty string    `json:"difficulty"` // Difficulty chosen for all levels, the level ones if empty
+	Date       time.Time `json:"date"`
+	Steps      int       `json:"steps"` // Total number of engine steps of the game
+	Inputs     Inputs    `json:"inputs"`
+}
+
+// New returns an empty replay of a game
+func New(seed uint64, configHash, levelName string) *Replay {
+	return &Replay{
+		Version:    Version,
+		Seed:       seed,
+		ConfigHash: configHash,
+		LevelName:  levelName,
+		Date:       time.Now(),
+	}
+}
+
+// Record appends the direction applied at the given step
+func (r *Replay) Record(step int, dir utils.Direction) {
+	r.Inputs = append(r.Inputs, Input{Step: step, Dir: dir})
+	r.Steps = max(r.Steps, step+1)
+}
+
+// InputAt returns the direction applied at the given step
+func (r *Replay) InputAt(step int) utils.Direction {
+	i := sort.Search(len(r.Inputs), func(i int) bool { return r.Inputs[i].Step >= step })
+	if i < len(r.Inputs) && r.Inputs[i].Step == step {
+		return r.Inputs[i].Dir
+	}
+	return utils.Direction{}
+}
+
+var dirLetters = map[utils.Direction]byte{{X: 0, Y: -1}: 'U', {X: 0, Y: 1}: 'D', {X: -1, Y: 0}: 'L', {X: 1, Y: 0}: 'R'}
+
+func (in Inputs) MarshalText() ([]byte, error) {
+	var b []byte
+	prev := 0
+	for _, i := range in {
+		letter, ok := dirLetters[i.Dir]
+		if !ok {
+			return nil, fmt.Errorf("invalid direction %v at step %d", i.Dir, i.Step)
+		}
+		b = strconv.AppendInt(b, int64(i.Step-prev), 10)
+		b = append(b, letter)
+		prev = i.Step
+	}
+	return b, nil
+}
+
+func (in *Inputs) UnmarshalText(text []byte) error {
+	*in = nil
+	step, start := 0, 0
+	for i, c := range text {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		delta, err := strconv.Atoi(string(text[start:i]))
+		if err != nil {
+			return fmt.Errorf("invalid inputs at offset %d: %w", start, err)
+		}
+		var dir utils.Direction
+		found := false
+		for d, letter := range dirLetters {
+			if letter == c {
+				dir, found = d, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid direction %q at offset %d", c, i)
+		}
+		step += delta
+		*in = append(*in, Input{Step: step, Dir: dir})
+		start = i + 1
+	}
+	if start != len(text) {
+		return errors.New("inputs end without a direction")
+	}
+	return nil
+}
+
+// Save writes the replay to the replays directory and returns the file path
+func Save(r *Replay) (string, error) {
+	dir, err := getReplaysPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("%s-%d.json", r.Date.Format("20060102-150405"), r.Seed))
+	return filename, os.WriteFile(filename, data, 0644)
+}
+
+// Load reads a replay file
+func Load(filename string) (*Replay, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid replay %s: %w", filename, err)
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("unsupported replay version %d", r.Version)
+	}
+	if !sort.SliceIsSorted(r.Inputs, func(i, j int) bool { return r.Inputs[i].Step < r.Inputs[j].Step }) {
+		return nil, errors.New("replay inputs are out of order")
+	}
+	return &r, nil
+}
+
+func getReplaysPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Create replays directory if it doesn't exist
+	dir := filepath.Join(configDir, "pacmantea", "replays")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,89 @@
+// Package snapshot saves the game in progress when the player quits, so that
+// it can be continued in the next run exactly where it was left. Every profile
+// has its own snapshot.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vinser/pacmantea/internal/state"
+)
+
+// Version of the snapshot file format. Snapshots of other versions are not restored.
+const Version = 3
+
+type file struct {
+	Version    int             `json:"version"`
+	ConfigHash string          `json:"config_hash"` // Configuration the game was played with
+	Date       time.Time       `json:"date"`
+	Game       json.RawMessage `json:"game"`
+}
+
+// Save writes the snapshot of the game played with the configuration
+func Save(configHash string, game any) error {
+	filename, err := getSnapshotPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(file{Version: Version, ConfigHash: configHash, Date: time.Now(), Game: data})
+	if err != nil {
+		return err
+	}
+	return state.WriteFile(filename, data, 0644)
+}
+
+// Load reads the snapshot into game. It fails with an error matching fs.ErrNotExist
+// if there is none, and when the snapshot is of another version or configuration.
+func Load(configHash string, game any) error {
+	filename, err := getSnapshotPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if f.Version != Version {
+		return fmt.Errorf("the saved game has unsupported version %d", f.Version)
+	}
+	if f.ConfigHash != configHash {
+		return errors.New("the saved game was played with another configuration")
+	}
+	if err := json.Unmarshal(f.Game, game); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the snapshot once there is no game to continue
+func Remove() error {
+	filename, err := getSnapshotPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func getSnapshotPath() (string, error) {
+	dir, err := state.ProfileDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snapshot.json"), nil
+}
@@ -0,0 +1,33 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Saved snapshots load back and leave no temporary files behind
+func TestSaveLoad(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	type game struct{ Score int }
+	for _, score := range []int{10, 20} {
+		if err := Save("hash", game{Score: score}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var loaded game
+	if err := Load("hash", &loaded); err != nil || loaded.Score != 20 {
+		t.Fatalf("loaded %+v (%v), want the score of 20", loaded, err)
+	}
+	filename, err := getSnapshotPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(filename), "*.tmp"))
+	if err != nil || len(files) > 0 {
+		t.Errorf("temporary files are left: %v (%v)", files, err)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// Export returns the saved state of the current profile as a readable JSON document,
+// an empty state if nothing has been saved yet
+func Export() ([]byte, error) {
+	filename, err := getSavePath()
+	if err != nil {
+		return nil, err
+	}
+	s, err := loadFile(filename)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	stateData, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(envelope{Version: Version, State: stateData}, "", "  ")
+}
+
+// Import checks the JSON document made by Export, migrating older versions,
+// and saves it as the state of the current profile
+func Import(data []byte) error {
+	stateData, err := migrate(data)
+	if err != nil {
+		return err
+	}
+	s := newState()
+	decoder := json.NewDecoder(bytes.NewReader(stateData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+	if s.ElapsedTime == nil {
+		s.ElapsedTime = make(map[string]int)
+	}
+	if err := s.validate(); err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+	return Save(s)
+}
+
+// Reset saves an empty state for the current profile, the previous one is kept as a backup
+func Reset() error {
+	return Save(newState())
+}
+
+// Check the values a game cannot produce
+func (s State) validate() error {
+	if s.GamesWon < 0 || s.HighScore < 0 {
+		return errors.New("the games won and the high score cannot be negative")
+	}
+	for level, seconds := range s.ElapsedTime {
+		if seconds < 0 {
+			return fmt.Errorf("negative elapsed time of level %q", level)
+		}
+	}
+	for key, board := range s.Leaderboards {
+		if len(board) > LeaderboardSize {
+			return fmt.Errorf("leaderboard %q has more than %d scores", key, LeaderboardSize)
+		}
+		if !sort.SliceIsSorted(board, func(i, j int) bool { return board[i].Score > board[j].Score }) {
+			return fmt.Errorf("leaderboard %q is not sorted by score", key)
+		}
+		for _, score := range board {
+			if score.Score <= 0 || score.Duration < 0 {
+				return fmt.Errorf("leaderboard %q has an invalid score of %q", key, score.Name)
+			}
+		}
+	}
+	return nil
+}
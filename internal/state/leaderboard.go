@@ -0,0 +1,49 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// Number of scores kept in each leaderboard
+const LeaderboardSize = 10
+
+// Score is a leaderboard entry
+type Score struct {
+	Name     string    `json:"name"`
+	Score    int       `json:"score"`
+	Level    string    `json:"level"`    // Level reached
+	Duration int       `json:"duration"` // Seconds of game time
+	Seed     uint64    `json:"seed"`
+	Date     time.Time `json:"date"`
+}
+
+// LeaderboardKey identifies the leaderboard of a level pack played at a difficulty
+func LeaderboardKey(configHash, difficulty string) string {
+	return configHash + "/" + difficulty
+}
+
+// Qualifies reports whether the score makes it to the leaderboard
+func (s State) Qualifies(key string, score int) bool {
+	board := s.Leaderboards[key]
+	return score > 0 && (len(board) < LeaderboardSize || score > board[len(board)-1].Score)
+}
+
+// AddScore puts the score into the leaderboard and returns its index there, or -1 if it did not make it
+func (s *State) AddScore(key string, score Score) int {
+	if !s.Qualifies(key, score.Score) {
+		return -1
+	}
+	if s.Leaderboards == nil {
+		s.Leaderboards = make(map[string][]Score)
+	}
+	board := s.Leaderboards[key]
+	// Earlier scores stay ahead of equal later ones
+	i := sort.Search(len(board), func(i int) bool { return board[i].Score < score.Score })
+	board = append(board[:i], append([]Score{score}, board[i:]...)...)
+	if len(board) > LeaderboardSize {
+		board = board[:LeaderboardSize]
+	}
+	s.Leaderboards[key] = board
+	return i
+}
@@ -0,0 +1,180 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultProfile is the profile of players who have not chosen one
+const DefaultProfile = "default"
+
+// Longest profile name
+const MaxProfileNameLength = 20
+
+// File in the save directory with the name of the profile used last
+const lastProfileFile = "profile"
+
+// Profile all saves go to
+var profile = DefaultProfile
+
+// Profile returns the name of the current profile
+func Profile() string {
+	return profile
+}
+
+// SetProfile makes the profile current, creating it if needed, and remembers it for the next run
+func SetProfile(name string) error {
+	if err := CreateProfile(name); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	profile = name
+	dir, err := getSaveDir()
+	if err != nil {
+		return err
+	}
+	return WriteFile(filepath.Join(dir, lastProfileFile), []byte(name), 0644)
+}
+
+// LastProfile returns the profile used in the last run
+func LastProfile() string {
+	dir, err := getSaveDir()
+	if err != nil {
+		return DefaultProfile
+	}
+	data, err := os.ReadFile(filepath.Join(dir, lastProfileFile))
+	if err != nil || checkProfileName(string(data)) != nil {
+		return DefaultProfile
+	}
+	return string(data)
+}
+
+// Profiles returns the names of all profiles
+func Profiles() ([]string, error) {
+	dir, err := getProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	if !slices.Contains(names, profile) {
+		names = append(names, profile) // The current profile has not been saved yet
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// CreateProfile creates an empty profile. It fails with os.ErrExist if there is one with the name.
+func CreateProfile(name string) error {
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+	dir, err := getProfilesDir()
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(filepath.Join(dir, name), 0755)
+}
+
+// RenameProfile renames the profile with all its saves
+func RenameProfile(name, newName string) error {
+	if err := checkProfileName(newName); err != nil {
+		return err
+	}
+	dir, err := getProfilesDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, newName)); err == nil {
+		return fmt.Errorf("profile %q already exists", newName)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(dir, name), filepath.Join(dir, newName)); err != nil {
+		return err
+	}
+	if name == profile {
+		return SetProfile(newName)
+	}
+	return nil
+}
+
+// DeleteProfile deletes the profile with all its saves. The current profile cannot be deleted.
+func DeleteProfile(name string) error {
+	if name == profile {
+		return errors.New("the current profile cannot be deleted")
+	}
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+	dir, err := getProfilesDir()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(dir, name))
+}
+
+// Profile names are used as directory names
+func checkProfileName(name string) error {
+	if name == "" || len([]rune(name)) > MaxProfileNameLength {
+		return fmt.Errorf("the profile name must be 1 to %d characters long", MaxProfileNameLength)
+	}
+	if strings.TrimSpace(name) != name {
+		return errors.New("the profile name cannot start or end with a space")
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_", r) {
+			return fmt.Errorf("the profile name cannot contain %q", r)
+		}
+	}
+	return nil
+}
+
+// ProfileDir returns the directory of the saves of the current profile
+func ProfileDir() (string, error) {
+	dir, err := getProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	profileDir := filepath.Join(dir, profile)
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		return "", err
+	}
+	return profileDir, nil
+}
+
+// Directory of all profiles. Saves and the game snapshot made before profiles existed
+// become the default profile.
+func getProfilesDir() (string, error) {
+	saveDir, err := getSaveDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(saveDir, "profiles")
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+	defaultDir := filepath.Join(dir, DefaultProfile)
+	if err := os.MkdirAll(defaultDir, 0755); err != nil {
+		return "", err
+	}
+	for _, name := range []string{saveFile, saveFile + backupSuffix, saveFile + damagedSuffix, "snapshot.json"} {
+		if err := os.Rename(filepath.Join(saveDir, name), filepath.Join(defaultDir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return dir, nil
+}
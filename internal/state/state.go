@@ -1,84 +1,67 @@
 package state
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"errors"
-	"hash/crc32"
-	"io"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
 
-var encryptionKey = getEncryptionKey()
-
 // --- Game State  ---
 type State struct {
 	Mute        bool           `json:"mute"`         // Disable sound effects
 	LevelName   string         `json:"level_name"`   // Current level
-	GamesWon    int            `json:"games won"`    // Total number of games won
+	GamesWon    int            `json:"games_won"`    // Total number of games won
 	HighScore   int            `json:"high_score"`   // Global high score
 	ElapsedTime map[string]int `json:"elapsed_time"` // Per-level elapsed time records in seconds by level name
-}
 
-// ========================
-// 🛡️ Security Functions
-// ========================
+	Difficulty  string `json:"difficulty"`   // Difficulty of all levels, the level ones if empty
+	PacmanBadge string `json:"pacman_badge"` // Badge style of Pac-Man in all levels, the level ones if empty
+	GhostBadges string `json:"ghost_badges"` // Badge style of ghosts in all levels, the level ones if empty
+	Theme       string `json:"theme"`        // Theme of all levels, the level ones if empty
+	Cells       string `json:"cells"`        // Shape of the maze tiles in all levels, the level ones if empty
+	Minimap     bool   `json:"minimap"`      // Show the minimap when the maze does not fit the terminal
 
-// Generate encryption key from system-specific data
-func getEncryptionKey() []byte {
-	user, _ := os.UserHomeDir()
-	hash := sha256.Sum256([]byte(user + "packman-secret-salt!"))
-	return hash[:] // 32 bytes for AES-256
+	PlayerName   string             `json:"player_name"`  // Name last entered for the leaderboard
+	Leaderboards map[string][]Score `json:"leaderboards"` // Best scores first by level pack and difficulty
 }
 
-// Encrypt data with AES-GCM
-func encrypt(data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
+// ========================
+// 💾 Save/Load Functions
+// ========================
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
+// Version of the save format
+const Version = 2
 
-	return gcm.Seal(nonce, nonce, data, nil), nil
+// Saved data. The state is kept raw until it is migrated to the current version.
+// Saves of version 1 are bare states without the envelope.
+type envelope struct {
+	Version int             `json:"version"`
+	State   json.RawMessage `json:"state"`
 }
 
-// Decrypt data with AES-GCM
-func decrypt(ciphertext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(ciphertext) < gcm.NonceSize() {
-		return nil, errors.New("invalid ciphertext")
-	}
-
-	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+// Migrations of the saved state to the next version by the version they start from
+var migrations = map[int]func(s map[string]any) error{
+	1: func(s map[string]any) error {
+		// The count of games won had a space in its name
+		if v, ok := s["games won"]; ok {
+			s["games_won"] = v
+			delete(s, "games won")
+		}
+		return nil
+	},
 }
 
-// ========================
-// 💾 Save/Load Functions
-// ========================
+// Suffixes of the previous save and the save that could not be loaded
+const (
+	backupSuffix  = ".bak"
+	damagedSuffix = ".damaged"
+)
 
+// Save writes the state atomically and keeps the previous save as a backup
 func Save(s State) error {
 	// Get save file path
 	filename, err := getSavePath()
@@ -86,68 +69,150 @@ func Save(s State) error {
 		return err
 	}
 	// Serialize game data to JSON
-	jsonData, err := json.Marshal(s)
+	stateData, err := json.Marshal(s)
 	if err != nil {
 		return err
 	}
-
-	// Calculate CRC32 checksum
-	crc := crc32.ChecksumIEEE(jsonData)
-
-	// Create buffer: [4-byte CRC32][JSON data]
-	buf := make([]byte, 4+len(jsonData))
-	binary.LittleEndian.PutUint32(buf[:4], crc)
-	copy(buf[4:], jsonData)
-
-	// Encrypt and write to file
-	encrypted, err := encrypt(buf)
+	jsonData, err := json.Marshal(envelope{Version: Version, State: stateData})
+	if err != nil {
+		return err
+	}
+	data, err := store.Encode(jsonData)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filename, encrypted, 0644)
+	if previous, err := os.ReadFile(filename); err == nil {
+		if err := WriteFile(filename+backupSuffix, previous, 0644); err != nil {
+			return err
+		}
+	}
+	return WriteFile(filename, data, 0644)
 }
 
-func Load() State {
-	var state State
-	state.ElapsedTime = make(map[string]int)
+// Load reads the saved state. Having no save is a fresh start. If the save cannot be read,
+// it is kept aside and the backup of the previous save is loaded instead. The error
+// tells what happened, the returned state is ready to use in any case.
+func Load() (State, error) {
 	// Get save file path
 	filename, err := getSavePath()
 	if err != nil {
-		return state
+		return newState(), err
 	}
-	// Read encrypted file
-	encrypted, err := os.ReadFile(filename)
-	if err != nil {
-		return state
+	state, err := loadFile(filename)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	damaged := filename + damagedSuffix
+	if renameErr := os.Rename(filename, damaged); renameErr != nil {
+		return state, fmt.Errorf("the save %s is damaged: %w", filename, err)
 	}
+	if backup, backupErr := loadFile(filename + backupSuffix); backupErr == nil {
+		return backup, fmt.Errorf("the save is damaged (%w), the previous save is loaded instead and the damaged one is kept as %s", err, damaged)
+	}
+	return state, fmt.Errorf("the save is damaged (%w) and has no backup, the progress starts over and the damaged save is kept as %s", err, damaged)
+}
 
-	// Decrypt data
-	decrypted, err := decrypt(encrypted)
+func newState() State {
+	return State{ElapsedTime: make(map[string]int)}
+}
+
+// Read the save file and migrate it to the current version
+func loadFile(filename string) (State, error) {
+	state := newState()
+	data, err := os.ReadFile(filename)
 	if err != nil {
-		return state
+		return state, err
 	}
-
-	// Verify minimum length (4 bytes CRC32 + at least 1 byte data)
-	if len(decrypted) < 5 {
-		return state
+	jsonData, err := decode(data)
+	if err != nil {
+		return state, err
+	}
+	stateData, err := migrate(jsonData)
+	if err != nil {
+		return state, err
 	}
+	// Deserialize JSON
+	if err := json.Unmarshal(stateData, &state); err != nil {
+		return newState(), fmt.Errorf("invalid state: %w", err)
+	}
+	if state.ElapsedTime == nil {
+		state.ElapsedTime = make(map[string]int)
+	}
+	return state, nil
+}
 
-	// Extract CRC32 (first 4 bytes)
-	loadedCRC := binary.LittleEndian.Uint32(decrypted[:4])
-	jsonData := decrypted[4:]
+// Migrate the saved data to the state of the current version
+func migrate(data []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid save: %w", err)
+	}
+	e := envelope{Version: 1, State: data}
+	if _, ok := fields["version"]; ok {
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("invalid save: %w", err)
+		}
+	}
+	if e.Version == Version {
+		return e.State, nil
+	}
+	if e.Version > Version || e.Version < 1 {
+		return nil, fmt.Errorf("unsupported save version %d, the latest is %d", e.Version, Version)
+	}
+	// Numbers are decoded as they are to keep large seeds exact
+	decoder := json.NewDecoder(bytes.NewReader(e.State))
+	decoder.UseNumber()
+	var s map[string]any
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	for v := e.Version; v < Version; v++ {
+		if err := migrations[v](s); err != nil {
+			return nil, fmt.Errorf("cannot migrate the save from version %d: %w", v, err)
+		}
+	}
+	return json.Marshal(s)
+}
 
-	// Verify checksum
-	if crc32.ChecksumIEEE(jsonData) != loadedCRC {
-		return state
+// WriteFile writes the file through a temporary file renamed over it, so that it is never left half written
+func WriteFile(filename string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // Nothing to remove once renamed
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
 	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
 
-	// Deserialize JSON
-	json.Unmarshal(jsonData, &state)
+// Name of the save file in the profile directory
+const saveFile = "savegame.dat"
 
-	return state
+func getSavePath() (string, error) {
+	dir, err := ProfileDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Path to save binary file
+	return filepath.Join(dir, saveFile), nil
 }
 
-func getSavePath() (string, error) {
+// Directory of all saves
+func getSaveDir() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
@@ -158,7 +223,5 @@ func getSavePath() (string, error) {
 	if err := os.MkdirAll(saveDir, 0755); err != nil {
 		return "", err
 	}
-
-	// Path to save binary file
-	return filepath.Join(saveDir, "savegame.dat"), nil
+	return saveDir, nil
 }
@@ -0,0 +1,271 @@
+package state
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Store is the format of the save file. It turns the saved JSON into the content
+// of the file and back, checking that the file is neither damaged nor tampered with.
+type Store interface {
+	Encode(data []byte) ([]byte, error)
+	Decode(file []byte) ([]byte, error)
+}
+
+// Names of the stores in the configuration
+const (
+	SignedStoreName    = "signed"    // JSON signed with the key of the installation
+	PlainStoreName     = "plain"     // Readable JSON for debugging and modding
+	EncryptedStoreName = "encrypted" // Format of the older versions of the game
+)
+
+// Store of new saves. Saves of any store are loaded, except that unsigned plain
+// saves are loaded only by the plain store, or anyone could edit them.
+var store Store = SignedStore{}
+
+// NewStore returns the store by its name, the signed one if the name is empty
+func NewStore(name string) (Store, error) {
+	switch name {
+	case "", SignedStoreName:
+		return SignedStore{}, nil
+	case PlainStoreName:
+		return PlainStore{}, nil
+	case EncryptedStoreName:
+		return EncryptedStore{}, nil
+	}
+	return nil, fmt.Errorf("unknown save format %q, the formats are %s, %s and %s", name, SignedStoreName, PlainStoreName, EncryptedStoreName)
+}
+
+// SetStore makes the named store save the state from now on
+func SetStore(name string) error {
+	s, err := NewStore(name)
+	if err != nil {
+		return err
+	}
+	store = s
+	return nil
+}
+
+// Decode the file with the store it was saved by
+func decode(file []byte) ([]byte, error) {
+	var s Store = EncryptedStore{}
+	_, plainStore := store.(PlainStore)
+	plain := bytes.HasPrefix(bytes.TrimSpace(file), []byte("{"))
+	switch {
+	case bytes.HasPrefix(file, signatureHeader):
+		s = SignedStore{}
+	case plain && plainStore:
+		s = PlainStore{}
+	}
+	data, err := s.Decode(file)
+	if err == nil {
+		return data, nil
+	}
+	if _, ok := s.(EncryptedStore); !ok {
+		// Encrypted files may start like the other formats by chance
+		if data, encryptedErr := (EncryptedStore{}).Decode(file); encryptedErr == nil {
+			return data, nil
+		}
+	} else if plain {
+		return nil, fmt.Errorf("the save is not signed, unsigned saves are loaded only with the %s save format", PlainStoreName)
+	}
+	return nil, err
+}
+
+// ========================
+// 📄 Plain Store
+// ========================
+
+type PlainStore struct{}
+
+func (PlainStore) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (PlainStore) Decode(file []byte) ([]byte, error) {
+	if !json.Valid(file) {
+		return nil, errors.New("invalid JSON")
+	}
+	return file, nil
+}
+
+// ========================
+// ✍️ Signed Store
+// ========================
+
+// Signed files start with the header and the HMAC of the JSON that follows it
+var signatureHeader = []byte("pacmantea-hmac-sha256 ")
+
+// Name of the file with the signing key of the installation in the save directory
+const keyFile = "save.key"
+
+type SignedStore struct{}
+
+func (SignedStore) Encode(data []byte) ([]byte, error) {
+	key, err := getSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	file := append([]byte{}, signatureHeader...)
+	file = append(file, hex.EncodeToString(sign(key, data))...)
+	file = append(file, '\n')
+	return append(file, data...), nil
+}
+
+func (SignedStore) Decode(file []byte) ([]byte, error) {
+	signature, data, ok := bytes.Cut(bytes.TrimPrefix(file, signatureHeader), []byte("\n"))
+	if !ok || !bytes.HasPrefix(file, signatureHeader) {
+		return nil, errors.New("missing signature")
+	}
+	mac, err := hex.DecodeString(string(signature))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	key, err := getSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, sign(key, data)) {
+		return nil, errors.New("signature mismatch, the save was changed or made by another installation")
+	}
+	return data, nil
+}
+
+func sign(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Read the signing key of the installation, a random key is created on the first use
+func getSigningKey() ([]byte, error) {
+	dir, err := getSaveDir()
+	if err != nil {
+		return nil, err
+	}
+	filename := filepath.Join(dir, keyFile)
+	key, err := os.ReadFile(filename)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := WriteFile(filename, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ========================
+// 🛡️ Encrypted Store
+// ========================
+
+var encryptionKey = getEncryptionKey()
+
+// Files are encrypted with a key derived from the home directory, they cannot be
+// loaded once it changes. The store is kept to load and write saves of older versions.
+type EncryptedStore struct{}
+
+func (EncryptedStore) Encode(data []byte) ([]byte, error) {
+	// Calculate CRC32 checksum
+	crc := crc32.ChecksumIEEE(data)
+
+	// Create buffer: [4-byte CRC32][JSON data]
+	buf := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint32(buf[:4], crc)
+	copy(buf[4:], data)
+
+	return encrypt(buf)
+}
+
+func (EncryptedStore) Decode(file []byte) ([]byte, error) {
+	// Decrypt data
+	decrypted, err := decrypt(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt: %w", err)
+	}
+
+	// Verify minimum length (4 bytes CRC32 + at least 1 byte data)
+	if len(decrypted) < 5 {
+		return nil, errors.New("the save is too short")
+	}
+
+	// Extract CRC32 (first 4 bytes)
+	loadedCRC := binary.LittleEndian.Uint32(decrypted[:4])
+	data := decrypted[4:]
+
+	// Verify checksum
+	if crc32.ChecksumIEEE(data) != loadedCRC {
+		return nil, errors.New("checksum mismatch")
+	}
+	return data, nil
+}
+
+// Generate encryption key from system-specific data
+func getEncryptionKey() []byte {
+	user, _ := os.UserHomeDir()
+	hash := sha256.Sum256([]byte(user + "packman-secret-salt!"))
+	return hash[:] // 32 bytes for AES-256
+}
+
+// Encrypt data with AES-GCM
+func encrypt(data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Decrypt data with AES-GCM
+func decrypt(ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+}
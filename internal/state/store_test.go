@@ -0,0 +1,52 @@
+package state
+
+import (
+	"os"
+	"testing"
+)
+
+// A save edited into unsigned JSON is damaged unless the plain store is configured
+func TestLoadUnsignedSave(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() { SetStore("") })
+	filename, err := getSavePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := []byte(`{"version":2,"state":{"high_score":999999}}`)
+
+	for _, name := range []string{SignedStoreName, EncryptedStoreName} {
+		if err := SetStore(name); err != nil {
+			t.Fatal(err)
+		}
+		for _, score := range []int{10, 20} {
+			if err := Save(State{HighScore: score}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filename, tampered, 0644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := Load()
+		if err == nil {
+			t.Fatalf("%s store: the unsigned save is loaded without a warning", name)
+		}
+		if s.HighScore != 10 {
+			t.Errorf("%s store: the high score is %d, want 10 from the backup", name, s.HighScore)
+		}
+		if _, err := os.Stat(filename + damagedSuffix); err != nil {
+			t.Errorf("%s store: the unsigned save is not kept aside: %v", name, err)
+		}
+	}
+
+	if err := SetStore(PlainStoreName); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load()
+	if err != nil || s.HighScore != 999999 {
+		t.Errorf("plain store: the high score is %d, want 999999 (%v)", s.HighScore, err)
+	}
+}
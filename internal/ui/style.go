@@ -1,19 +1,78 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"cmp"
 
-// Define styles for different elements
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/vinser/pacmantea/internal/config"
+)
+
+// Default colours of the elements, used where the theme does not set them
+const (
+	defaultWall       = "2"  // Green
+	defaultDot        = "15" // White
+	defaultEnergizer  = "4"  // Blue
+	defaultDoor       = "13" // Light magenta
+	defaultPacman     = "3"  // Yellow
+	defaultRampant    = "4"  // Blue
+	defaultFrightened = "12" // Light blue
+	defaultEyes       = "15" // White
+	defaultHUD        = "3"  // Yellow
+)
+
+// Define styles for different elements, ApplyTheme sets them from the theme
 var (
-	WallStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))            // Green
-	PacmanStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true) // Yellow
-	DotStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("15"))           // White
-	EnergyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Bold(true) // Blue
+	WallStyle    lipgloss.Style
+	PacmanStyle  lipgloss.Style
+	RampantStyle lipgloss.Style // Pac-Man in the rampant state
+	DotStyle     lipgloss.Style
+	EnergyStyle  lipgloss.Style
+	DoorStyle    lipgloss.Style
+	HUDStyle     lipgloss.Style                                 // Titles and highlights
+	FlashStyle   = lipgloss.NewStyle().Reverse(true).Bold(true) // Highlighted HUD items
+	MapStyle     = lipgloss.NewStyle().Faint(true)              // Minimap outside the viewport
 )
 
-// Define styles for different ghosts
+// Define styles for ghosts in special states
 var (
-	BlinkyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)   // Red
-	InkyStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)   // Cyan
-	PinkyStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Bold(true) // Pink
-	ClydeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true) // Orange
+	FrightenedStyle lipgloss.Style
+	EyesStyle       lipgloss.Style
 )
+
+func init() {
+	ApplyTheme(config.Theme{})
+}
+
+// ApplyTheme sets the styles from the colours of the theme. The colours are brought
+// down to the colour profile of the terminal by lipgloss.
+func ApplyTheme(theme config.Theme) {
+	WallStyle = colorStyle(theme.Wall, defaultWall)
+	PacmanStyle = colorStyle(theme.Pacman, defaultPacman).Bold(true)
+	RampantStyle = colorStyle(theme.Rampant, defaultRampant).Bold(true)
+	DotStyle = colorStyle(theme.Dot, defaultDot)
+	EnergyStyle = colorStyle(theme.Energizer, defaultEnergizer).Bold(true)
+	DoorStyle = colorStyle(theme.Door, defaultDoor)
+	HUDStyle = colorStyle(theme.HUD, defaultHUD).Bold(true)
+	FrightenedStyle = colorStyle(theme.Frightened, defaultFrightened)
+	EyesStyle = colorStyle(theme.Eyes, defaultEyes)
+}
+
+func colorStyle(color, fallback string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(cmp.Or(color, fallback)))
+}
+
+// Monochrome reports whether the terminal shows no colours or text attributes at all
+func Monochrome() bool {
+	return lipgloss.ColorProfile() == termenv.Ascii
+}
+
+// GhostStyle returns the style of a ghost of the color
+func GhostStyle(color string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Bold(true)
+}
+
+// FruitStyle returns the style of a bonus fruit of the color
+func FruitStyle(color string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+}
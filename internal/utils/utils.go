@@ -1,7 +1,8 @@
 package utils
 
 import (
-	"math/rand"
+	"math/rand/v2"
+	"slices"
 	"sort"
 	"time"
 )
@@ -21,23 +22,12 @@ const (
 	MazeCenter   proximity = false
 )
 
-// Sort available (free) positions based on their distance from the center of the maze in a specified proximity.
-func TraverseOrder(maze []string, prox proximity) []Point {
-	rows := len(maze)
-	cols := len(maze[0])
-
+// Sort free positions of a maze of the given size based on their distance from the center of the maze in a specified proximity.
+func TraverseOrder(free []Point, width, height int, prox proximity) []Point {
 	// Calculate the center of the rectangle
-	centerPoint := Point{X: int(float64(cols-1) / 2.0), Y: int(float64(rows-1) / 2.0)}
-
-	// Create a list of all cells with their coordinates and values
-	var points []Point
-	for y, row := range maze {
-		for x, r := range row {
-			if r != '#' && r != 'o' && r != 'C' && r != 'B' && r != 'I' && r != 'P' && r != 'Y' { // Skip occupied points in the maze
-				points = append(points, Point{X: x, Y: y})
-			}
-		}
-	}
+	centerPoint := Point{X: int(float64(width-1) / 2.0), Y: int(float64(height-1) / 2.0)}
+
+	points := slices.Clone(free)
 	// Sort points by distance from the center
 	sort.Slice(points, func(i, j int) bool {
 		distI := distanceSquare(points[i], centerPoint)
@@ -66,27 +56,42 @@ func distanceSquare(p1, p2 Point) float64 {
 	return float64((p1.X-p2.X)*(p1.X-p2.X) + (p1.Y-p2.Y)*(p1.Y-p2.Y))
 }
 
-// Seed local random number generator
-var Rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+// Rand is a seeded random number generator owned by a single game
+type Rand struct {
+	*rand.Rand
+	Seed uint64 // Seed the generator was created with
+	src  *rand.PCG
+}
+
+// NewRand returns a random number generator seeded with seed
+func NewRand(seed uint64) *Rand {
+	src := rand.NewPCG(seed, seed)
+	return &Rand{Rand: rand.New(src), Seed: seed, src: src}
+}
+
+// MarshalBinary returns the current state of the generator
+func (r *Rand) MarshalBinary() ([]byte, error) {
+	return r.src.MarshalBinary()
+}
+
+// UnmarshalBinary restores the state of the generator
+func (r *Rand) UnmarshalBinary(data []byte) error {
+	return r.src.UnmarshalBinary(data)
+}
+
+// NewSeed returns a seed derived from the current time
+func NewSeed() uint64 {
+	return uint64(time.Now().UnixNano())
+}
 
-func RandomDirections() []Point {
+func RandomDirections(rng *Rand) []Point {
 	directions := []Point{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
-	Rng.Shuffle(len(directions), func(i, j int) {
+	rng.Shuffle(len(directions), func(i, j int) {
 		directions[i], directions[j] = directions[j], directions[i]
 	})
 	return directions
 }
 
-// Replace a character in a string at a given index
-func ReplaceAtIndex(s string, r rune, index int) string {
-	runes := []rune(s)
-	if index < 0 || index >= len(runes) {
-		return s // Return the original string if the index is out of bounds
-	}
-	runes[index] = r
-	return string(runes)
-}
-
 // Compute the maximum of two integers
 func max(a, b int) int {
 	if a > b {